@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/e6a5/tiv/render"
+)
+
+// EXIF tag holding the image orientation
+const exifOrientationTag = 0x0112
+
+// exifOrientation returns the EXIF orientation of a JPEG or TIFF file, or
+// OrientationNormal when the file has none
+func exifOrientation(data []byte) render.Orientation {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		return jpegOrientation(data)
+	case bytes.HasPrefix(data, []byte("II*\x00")), bytes.HasPrefix(data, []byte("MM\x00*")):
+		return tiffOrientation(data)
+	}
+	return render.OrientationNormal
+}
+
+// jpegOrientation walks the JPEG marker segments up to the image data
+// looking for an APP1 Exif segment
+func jpegOrientation(data []byte) render.Orientation {
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			break
+		}
+		marker := data[i+1]
+
+		switch {
+		case marker == 0xFF: // Fill byte
+			i++
+			continue
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD8): // No payload
+			i += 2
+			continue
+		case marker == 0xD9 || marker == 0xDA: // End of image, start of scan
+			return render.OrientationNormal
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			break
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return render.OrientationNormal
+}
+
+// tiffOrientation reads the orientation tag from the first IFD of a TIFF
+// structure, as found in TIFF files and JPEG Exif segments
+func tiffOrientation(tiff []byte) render.Orientation {
+	if len(tiff) < 8 {
+		return render.OrientationNormal
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return render.OrientationNormal
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return render.OrientationNormal
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return render.OrientationNormal
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+
+	for n := 0; n < entries; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) != exifOrientationTag {
+			continue
+		}
+
+		// Orientation is a single SHORT stored in the value field
+		orientation := render.Orientation(order.Uint16(tiff[entry+8:]))
+		if orientation < render.OrientationNormal || orientation > render.OrientationRotate270 {
+			return render.OrientationNormal
+		}
+		return orientation
+	}
+	return render.OrientationNormal
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/e6a5/tiv/render"
+)
+
+// tiffWithOrientation builds a TIFF header and first IFD holding an unrelated
+// tag followed by the orientation tag
+func tiffWithOrientation(order binary.ByteOrder, orientation uint16) []byte {
+	var buf bytes.Buffer
+	if order == binary.LittleEndian {
+		buf.WriteString("II")
+	} else {
+		buf.WriteString("MM")
+	}
+	binary.Write(&buf, order, uint16(42))
+	binary.Write(&buf, order, uint32(8)) // First IFD right after the header
+
+	binary.Write(&buf, order, uint16(2)) // Entry count
+	// ImageWidth, LONG, 1, 640
+	binary.Write(&buf, order, []uint16{0x0100, 4})
+	binary.Write(&buf, order, []uint32{1, 640})
+	// Orientation, SHORT, 1, value padded to four bytes
+	binary.Write(&buf, order, []uint16{exifOrientationTag, 3})
+	binary.Write(&buf, order, uint32(1))
+	binary.Write(&buf, order, []uint16{orientation, 0})
+
+	binary.Write(&buf, order, uint32(0)) // No next IFD
+	return buf.Bytes()
+}
+
+// jpegSegment returns a JPEG marker segment with the given payload
+func jpegSegment(marker byte, payload []byte) []byte {
+	segment := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// jpegWithSegments builds the start of a JPEG file: SOI, the segments and
+// the start of scan
+func jpegWithSegments(segments ...[]byte) []byte {
+	data := []byte{0xFF, 0xD8}
+	for _, s := range segments {
+		data = append(data, s...)
+	}
+	return append(data, jpegSegment(0xDA, []byte{0, 0, 0})...)
+}
+
+// jpegWithOrientation builds a JPEG with a JFIF header and an Exif segment
+func jpegWithOrientation(order binary.ByteOrder, orientation uint16) []byte {
+	exif := append([]byte("Exif\x00\x00"), tiffWithOrientation(order, orientation)...)
+	return jpegWithSegments(
+		jpegSegment(0xE0, []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00")),
+		jpegSegment(0xE1, exif),
+	)
+}
+
+func TestExifOrientationValues(t *testing.T) {
+	orders := []struct {
+		name  string
+		order binary.ByteOrder
+	}{
+		{"II", binary.LittleEndian},
+		{"MM", binary.BigEndian},
+	}
+
+	for _, o := range orders {
+		for v := uint16(0); v <= 10; v++ {
+			want := render.Orientation(v)
+			if v < 1 || v > 8 {
+				want = render.OrientationNormal
+			}
+
+			if got := exifOrientation(tiffWithOrientation(o.order, v)); got != want {
+				t.Errorf("%s TIFF with orientation %d = %d, want %d", o.name, v, got, want)
+			}
+			if got := exifOrientation(jpegWithOrientation(o.order, v)); got != want {
+				t.Errorf("%s JPEG with orientation %d = %d, want %d", o.name, v, got, want)
+			}
+		}
+
+		if got := exifOrientation(tiffWithOrientation(o.order, 0xFFFF)); got != render.OrientationNormal {
+			t.Errorf("%s TIFF with orientation 65535 = %d, want %d", o.name, got, render.OrientationNormal)
+		}
+	}
+}
+
+func TestExifOrientationTruncated(t *testing.T) {
+	tiff := tiffWithOrientation(binary.BigEndian, uint16(render.OrientationRotate90))
+	// Header, entry count and both 12-byte entries
+	entriesEnd := 8 + 2 + 2*12
+
+	for n := 0; n < len(tiff); n++ {
+		want := render.OrientationNormal
+		if n >= entriesEnd {
+			want = render.OrientationRotate90
+		}
+		if got := tiffOrientation(tiff[:n]); got != want {
+			t.Errorf("TIFF cut to %d bytes = %d, want %d", n, got, want)
+		}
+	}
+
+	jpeg := jpegWithOrientation(binary.BigEndian, uint16(render.OrientationRotate90))
+	exifEnd := len(jpeg) - len(jpegSegment(0xDA, []byte{0, 0, 0}))
+
+	for n := 0; n < len(jpeg); n++ {
+		want := render.OrientationNormal
+		if n >= exifEnd {
+			want = render.OrientationRotate90
+		}
+		if got := exifOrientation(jpeg[:n]); got != want {
+			t.Errorf("JPEG cut to %d bytes = %d, want %d", n, got, want)
+		}
+	}
+}
+
+func TestExifOrientationGarbage(t *testing.T) {
+	valid := tiffWithOrientation(binary.LittleEndian, uint16(render.OrientationRotate180))
+
+	badMagic := append([]byte(nil), valid...)
+	badMagic[2] = 43
+
+	badIFD := append([]byte(nil), valid...)
+	binary.LittleEndian.PutUint32(badIFD[4:], 0xFFFFFFF0)
+
+	lowIFD := append([]byte(nil), valid...)
+	binary.LittleEndian.PutUint32(lowIFD[4:], 2)
+
+	manyEntries := append([]byte(nil), valid...)
+	binary.LittleEndian.PutUint16(manyEntries[8:], 0xFFFF)
+
+	exif := append([]byte("Exif\x00\x00"), valid...)
+	overlong := jpegSegment(0xE1, exif)
+	binary.BigEndian.PutUint16(overlong[2:], 0xFFFF)
+
+	tests := []struct {
+		name string
+		data []byte
+		want render.Orientation
+	}{
+		{"empty", nil, render.OrientationNormal},
+		{"PNG", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), render.OrientationNormal},
+		{"bad byte order", append([]byte("XX"), valid[2:]...), render.OrientationNormal},
+		{"bad TIFF magic", badMagic, render.OrientationNormal},
+		{"IFD past the end", badIFD, render.OrientationNormal},
+		{"IFD inside the header", lowIFD, render.OrientationNormal},
+		{"entry count past the end", manyEntries, render.OrientationRotate180},
+		{"APP1 without Exif header", jpegWithSegments(jpegSegment(0xE1, valid)), render.OrientationNormal},
+		{"APP1 with garbage", jpegWithSegments(jpegSegment(0xE1, []byte("Exif\x00\x00garbage!"))), render.OrientationNormal},
+		{"APP1 longer than the file", append([]byte{0xFF, 0xD8}, overlong...), render.OrientationNormal},
+		{"APP1 shorter than its length field", jpegWithSegments([]byte{0xFF, 0xE1, 0x00, 0x01}), render.OrientationNormal},
+		{"marker without 0xFF", []byte{0xFF, 0xD8, 0x00, 0xE1, 0x00, 0x10}, render.OrientationNormal},
+		{"Exif after the start of scan", append(jpegWithSegments(), jpegSegment(0xE1, exif)...), render.OrientationNormal},
+		{"Exif after XMP and fill bytes", jpegWithSegments(
+			jpegSegment(0xE1, []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta/>")),
+			[]byte{0xFF, 0xFF},
+			jpegSegment(0xE1, exif),
+		), render.OrientationRotate180},
+	}
+
+	for _, tt := range tests {
+		if got := exifOrientation(tt.data); got != tt.want {
+			t.Errorf("%s: exifOrientation = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeImageFileOrientation(t *testing.T) {
+	// A real 4x2 JPEG with a Rotate90 Exif segment spliced in after SOI
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 2)), nil); err != nil {
+		t.Fatal(err)
+	}
+	exif := append([]byte("Exif\x00\x00"), tiffWithOrientation(binary.BigEndian, uint16(render.OrientationRotate90))...)
+	data := append([]byte{0xFF, 0xD8}, jpegSegment(0xE1, exif)...)
+	data = append(data, buf.Bytes()[2:]...)
+
+	filename := filepath.Join(t.TempDir(), "rotated.jpg")
+	if err := os.WriteFile(filename, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		autoOrient    bool
+		width, height int
+	}{
+		{true, 2, 4},
+		{false, 4, 2},
+	}
+
+	for _, tt := range tests {
+		encoded, err := encodeImageFile(filename, previewOptions{autoOrient: tt.autoOrient})
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Re-encoded as PNG, which carries no orientation for the terminal
+		img, err := png.Decode(bytes.NewReader(decoded))
+		if err != nil {
+			t.Fatalf("autoOrient=%v: not re-encoded as PNG: %v", tt.autoOrient, err)
+		}
+		if b := img.Bounds(); b.Dx() != tt.width || b.Dy() != tt.height {
+			t.Errorf("autoOrient=%v: got %dx%d, want %dx%d", tt.autoOrient, b.Dx(), b.Dy(), tt.width, tt.height)
+		}
+	}
+
+	// Files without an orientation are sent as they are
+	plain := filepath.Join(t.TempDir(), "plain.jpg")
+	if err := os.WriteFile(plain, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := encodeImageFile(plain, previewOptions{autoOrient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encoded != base64.StdEncoding.EncodeToString(buf.Bytes()) {
+		t.Error("file without an orientation was re-encoded")
+	}
+}
@@ -3,3 +3,5 @@ module github.com/e6a5/tiv
 go 1.23.2
 
 require golang.org/x/image v0.21.0
+
+require golang.org/x/text v0.19.0 // indirect
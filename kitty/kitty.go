@@ -0,0 +1,149 @@
+// Package kitty writes images with the Kitty terminal graphics protocol,
+// supported by Kitty, Ghostty, Konsole and WezTerm.
+//
+// Image data is base64 encoded and sent in chunks of at most 4096 bytes, as
+// the protocol requires:
+//
+//	err := kitty.Encode(os.Stdout, img, kitty.Options{Columns: 40, Rows: 20})
+package kitty
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// Format is the pixel format image data is transmitted in
+type Format int
+
+const (
+	FormatPNG  Format = iota // PNG file data (f=100)
+	FormatRGBA               // Raw 8-bit RGBA pixels (f=32)
+)
+
+// Largest base64 payload a single escape sequence may carry
+const chunkSize = 4096
+
+// Options controls how an image is transmitted and placed
+type Options struct {
+	Format      Format // Transmission format (FormatPNG by default)
+	ID          uint32 // Image ID (i=), so the image can be placed or deleted later; 0 lets the terminal choose
+	PlacementID uint32 // Placement ID (p=), to tell several placements of one image apart
+	Columns     int    // Cells across to scale the image to (c=); 0 keeps the image size
+	Rows        int    // Cells down to scale the image to (r=); 0 keeps the image size
+	ZIndex      int    // Stacking order (z=); negative values draw the image under the text
+	Placeholder bool   // Create a virtual placement (U=1) shown wherever Placeholders are printed; needs ID, Columns and Rows
+}
+
+// Encode transmits img and displays it at the cursor, in opts.Format
+func Encode(w io.Writer, img image.Image, opts Options) error {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return fmt.Errorf("kitty: image has dimensions %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	switch opts.Format {
+	case FormatPNG:
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return fmt.Errorf("kitty: %w", err)
+		}
+		return transmit(w, "f=100", buf.Bytes(), opts)
+
+	case FormatRGBA:
+		rgba, ok := img.(*image.NRGBA)
+		if !ok || rgba.Stride != 4*bounds.Dx() {
+			rgba = image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+			draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+		}
+		format := fmt.Sprintf("f=32,s=%d,v=%d", bounds.Dx(), bounds.Dy())
+		return transmit(w, format, rgba.Pix[:4*bounds.Dx()*bounds.Dy()], opts)
+	}
+	return fmt.Errorf("kitty: unknown format %d", opts.Format)
+}
+
+// EncodePNG transmits already encoded PNG data and displays it at the
+// cursor, sparing a decode and re-encode. opts.Format is ignored.
+func EncodePNG(w io.Writer, data []byte, opts Options) error {
+	return transmit(w, "f=100", data, opts)
+}
+
+// Delete removes the placement of image id with the given placement ID from
+// the screen, or every placement of the image when placement is 0, and
+// frees the image data. An id of 0 deletes every image on screen.
+func Delete(w io.Writer, id, placement uint32) error {
+	var keys string
+	switch {
+	case id == 0:
+		keys = "a=d,d=A"
+	case placement == 0:
+		keys = fmt.Sprintf("a=d,d=I,i=%d", id)
+	default:
+		keys = fmt.Sprintf("a=d,d=I,i=%d,p=%d", id, placement)
+	}
+	_, err := fmt.Fprintf(w, "\033_G%s,q=2\033\\", keys)
+	return err
+}
+
+// transmit writes a transmit-and-display command for data, split into
+// chunks. Only the first chunk carries the control keys; every chunk but
+// the last has m=1. Each chunk is a separate write, so that writers which
+// wrap escape sequences for terminal multiplexers see them one at a time.
+func transmit(w io.Writer, format string, data []byte, opts Options) error {
+	if opts.Placeholder && (opts.ID == 0 || opts.Columns < 1 || opts.Rows < 1) {
+		return fmt.Errorf("kitty: placeholder placement needs an image ID, columns and rows")
+	}
+
+	var keys strings.Builder
+	keys.WriteString("a=T," + format)
+	if opts.ID != 0 {
+		fmt.Fprintf(&keys, ",i=%d", opts.ID)
+	}
+	if opts.PlacementID != 0 {
+		fmt.Fprintf(&keys, ",p=%d", opts.PlacementID)
+	}
+	if opts.Columns > 0 {
+		fmt.Fprintf(&keys, ",c=%d", opts.Columns)
+	}
+	if opts.Rows > 0 {
+		fmt.Fprintf(&keys, ",r=%d", opts.Rows)
+	}
+	if opts.ZIndex != 0 {
+		fmt.Fprintf(&keys, ",z=%d", opts.ZIndex)
+	}
+	if opts.Placeholder {
+		keys.WriteString(",U=1")
+	}
+	// Suppress the terminal's OK and error replies, which would otherwise
+	// turn up as input
+	keys.WriteString(",q=2")
+
+	payload := base64.StdEncoding.EncodeToString(data)
+
+	var buf bytes.Buffer
+	for first := true; first || len(payload) > 0; first = false {
+		chunk := payload[:min(chunkSize, len(payload))]
+		payload = payload[len(chunk):]
+
+		more := 0
+		if len(payload) > 0 {
+			more = 1
+		}
+
+		buf.Reset()
+		buf.WriteString("\033_G")
+		if first {
+			buf.WriteString(keys.String() + ",")
+		}
+		fmt.Fprintf(&buf, "m=%d;%s\033\\", more, chunk)
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
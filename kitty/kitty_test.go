@@ -0,0 +1,197 @@
+package kitty
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// writeRecorder keeps every write separately
+type writeRecorder struct {
+	writes []string
+}
+
+// Write implements io.Writer
+func (r *writeRecorder) Write(p []byte) (int, error) {
+	r.writes = append(r.writes, string(p))
+	return len(p), nil
+}
+
+// command is a parsed graphics escape sequence
+type command struct {
+	keys    string // Control keys, without the trailing m= key
+	more    string // Value of the m= key
+	payload string
+}
+
+// parseCommand splits a graphics escape sequence into its parts
+func parseCommand(t *testing.T, seq string) command {
+	t.Helper()
+
+	if !strings.HasPrefix(seq, "\033_G") || !strings.HasSuffix(seq, "\033\\") {
+		t.Fatalf("%q is not a graphics command", seq)
+	}
+	body := strings.TrimSuffix(strings.TrimPrefix(seq, "\033_G"), "\033\\")
+
+	control, payload, _ := strings.Cut(body, ";")
+	keys, more, ok := strings.Cut(control, "m=")
+	if !ok {
+		t.Fatalf("%q has no m= key", seq)
+	}
+	return command{keys: strings.TrimSuffix(keys, ","), more: more, payload: payload}
+}
+
+func TestChunking(t *testing.T) {
+	// 3 raw bytes encode to 4 base64 bytes, so 3072 bytes fill one chunk
+	tests := []struct {
+		size   int
+		chunks []int // Base64 bytes in each chunk
+	}{
+		{0, []int{0}},
+		{3069, []int{4092}},
+		{3072, []int{4096}},
+		{3073, []int{4096, 4}},
+		{3 * 3072, []int{4096, 4096, 4096}},
+		{3*3072 + 1, []int{4096, 4096, 4096, 4}},
+	}
+
+	for _, tt := range tests {
+		data := bytes.Repeat([]byte{0xA5}, tt.size)
+
+		var rec writeRecorder
+		if err := EncodePNG(&rec, data, Options{}); err != nil {
+			t.Fatal(err)
+		}
+		if len(rec.writes) != len(tt.chunks) {
+			t.Fatalf("%d bytes sent in %d writes, want %d", tt.size, len(rec.writes), len(tt.chunks))
+		}
+
+		var payload strings.Builder
+		for i, seq := range rec.writes {
+			cmd := parseCommand(t, seq)
+
+			wantMore := "1"
+			if i == len(rec.writes)-1 {
+				wantMore = "0"
+			}
+			if cmd.more != wantMore {
+				t.Errorf("%d bytes, chunk %d: m=%s, want m=%s", tt.size, i, cmd.more, wantMore)
+			}
+			if len(cmd.payload) != tt.chunks[i] {
+				t.Errorf("%d bytes, chunk %d: %d base64 bytes, want %d", tt.size, i, len(cmd.payload), tt.chunks[i])
+			}
+			payload.WriteString(cmd.payload)
+		}
+
+		got, err := base64.StdEncoding.DecodeString(payload.String())
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("%d bytes: chunks do not reassemble to the data (%v)", tt.size, err)
+		}
+	}
+}
+
+func TestControlKeys(t *testing.T) {
+	opts := Options{ID: 7, PlacementID: 3, Columns: 40, Rows: 20, ZIndex: -1, Placeholder: true}
+
+	var rec writeRecorder
+	if err := EncodePNG(&rec, make([]byte, 2*3072), opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.writes) != 2 {
+		t.Fatalf("sent %d chunks, want 2", len(rec.writes))
+	}
+
+	want := "a=T,f=100,i=7,p=3,c=40,r=20,z=-1,U=1,q=2"
+	if cmd := parseCommand(t, rec.writes[0]); cmd.keys != want {
+		t.Errorf("first chunk keys = %q, want %q", cmd.keys, want)
+	}
+	if cmd := parseCommand(t, rec.writes[1]); cmd.keys != "" {
+		t.Errorf("second chunk keys = %q, want none", cmd.keys)
+	}
+
+	// Zero options leave their keys out
+	rec = writeRecorder{}
+	if err := EncodePNG(&rec, []byte("png"), Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if cmd := parseCommand(t, rec.writes[0]); cmd.keys != "a=T,f=100,q=2" {
+		t.Errorf("keys for zero options = %q, want %q", cmd.keys, "a=T,f=100,q=2")
+	}
+}
+
+func TestPlaceholderNeedsPlacement(t *testing.T) {
+	for _, opts := range []Options{
+		{Placeholder: true, Columns: 4, Rows: 2},
+		{Placeholder: true, ID: 1, Rows: 2},
+		{Placeholder: true, ID: 1, Columns: 4},
+	} {
+		if err := EncodePNG(&bytes.Buffer{}, []byte("png"), opts); err == nil {
+			t.Errorf("EncodePNG with %+v succeeded, want an error", opts)
+		}
+	}
+}
+
+func TestEncodeRGBA(t *testing.T) {
+	// A 2x2 view into a 4x3 image, so rows are 16 bytes apart but only 8
+	// bytes of each belong to the view
+	full := image.NewNRGBA(image.Rect(0, 0, 4, 3))
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 4; x++ {
+			full.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 0x80, A: uint8(0x40 * (x + 1))})
+		}
+	}
+	sub := full.SubImage(image.Rect(1, 1, 3, 3)).(*image.NRGBA)
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, sub, Options{Format: FormatRGBA}); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := parseCommand(t, buf.String())
+	if want := "a=T,f=32,s=2,v=2,q=2"; cmd.keys != want {
+		t.Errorf("keys = %q, want %q", cmd.keys, want)
+	}
+
+	got, err := base64.StdEncoding.DecodeString(cmd.payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		1, 1, 0x80, 0x80, 2, 1, 0x80, 0xC0,
+		1, 2, 0x80, 0x80, 2, 2, 0x80, 0xC0,
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("pixels = %v, want %v", got, want)
+	}
+}
+
+func TestEncodeEmpty(t *testing.T) {
+	if err := Encode(&bytes.Buffer{}, image.NewNRGBA(image.Rect(0, 0, 0, 3)), Options{}); err == nil {
+		t.Error("Encode of an empty image succeeded, want an error")
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		id, placement uint32
+		want          string
+	}{
+		{0, 0, "\033_Ga=d,d=A,q=2\033\\"},
+		{0, 5, "\033_Ga=d,d=A,q=2\033\\"},
+		{42, 0, "\033_Ga=d,d=I,i=42,q=2\033\\"},
+		{42, 5, "\033_Ga=d,d=I,i=42,p=5,q=2\033\\"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := Delete(&buf, tt.id, tt.placement); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("Delete(%d, %d) = %q, want %q", tt.id, tt.placement, buf.String(), tt.want)
+		}
+	}
+}
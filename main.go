@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"image"
@@ -8,6 +9,11 @@ import (
 	"image/png"
 	"io"
 	"os"
+	"strings"
+
+	"github.com/e6a5/tiv/render"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
 )
 
 // Version is set by build flags
@@ -16,26 +22,64 @@ var Version = "development"
 func main() {
 	var config Config
 	var showVersion bool
+	var probe bool
+	var listModes bool
 	var colorMode string
+	var colorMetric string
+	var lumaModel string
+	var palette string
+	var background string
+	var crop string
+	var fitMode string
+	var gravity string
+	var charset string
+	var calibrateFont string
 	
 	// Define CLI flags
 	flag.IntVar(&config.Width, "w", 80, "Output width in characters")
 	flag.IntVar(&config.Width, "width", 80, "Output width in characters")
 	flag.IntVar(&config.Height, "h", 0, "Output height in characters (auto-calculated if 0)")
 	flag.IntVar(&config.Height, "height", 0, "Output height in characters (auto-calculated if 0)")
+	flag.StringVar(&fitMode, "fit", "contain", "Fit to the width x height box: 'contain', 'cover' (crop), 'fill' (stretch), or 'none' (one cell per pixel)")
+	flag.Float64Var(&config.CellAspect, "cell-aspect", 0, "Character cell width divided by height, e.g. 0.5 (0 = detect from the terminal)")
 	flag.BoolVar(&config.Invert, "i", false, "Invert brightness levels")
 	flag.BoolVar(&config.Invert, "invert", false, "Invert brightness levels")
 	flag.Float64Var(&config.Contrast, "c", 1.0, "Contrast adjustment (0.5-2.0, default 1.0)")
 	flag.Float64Var(&config.Contrast, "contrast", 1.0, "Contrast adjustment (0.5-2.0, default 1.0)")
+	flag.StringVar(&config.Mode, "mode", "", "Rendering mode, e.g. 'ascii', 'blocks', 'dither' (overrides -b and -d; see -list-modes)")
+	flag.BoolVar(&listModes, "list-modes", false, "List available rendering modes")
+	flag.StringVar(&charset, "charset", "", "Character ramp: a preset name or a literal string ordered from least to most ink (also used by -b)")
+	flag.StringVar(&calibrateFont, "calibrate-font", "", "Sort and space the character ramp by glyph ink coverage measured from a TTF/OTF font")
+	flag.Float64Var(&config.EdgeThreshold, "edge-threshold", render.DefaultEdgeThreshold, "Gradient magnitude (0-1) above which edges mode draws a line glyph")
+	flag.BoolVar(&config.EdgeOverlay, "edge-overlay", false, "Draw edges mode lines over the brightness ramp instead of blank space")
+	flag.StringVar(&config.Resample, "resample", "", "Resize to the output grid first with a kernel: 'nearest', 'box', 'bilinear', 'catmull-rom', or 'lanczos3'")
+	flag.StringVar(&background, "bg", "terminal", "Background for transparent pixels: 'terminal', 'checkerboard', or a hex color like '#fff'")
+	flag.BoolVar(&config.BlankTransparent, "blank-transparent", false, "Leave fully transparent cells blank so the terminal background shows through")
+	flag.BoolVar(&config.LinearLight, "linear", false, "Average pixels in linear light (gamma-correct) instead of raw sRGB values")
+	flag.StringVar(&lumaModel, "luma", "601", "Brightness coefficients: '601' (Rec.601), '709' (Rec.709), or 'perceptual' (CIE L*)")
 	flag.BoolVar(&config.UseBlocks, "b", false, "Use Unicode block characters for higher resolution")
 	flag.BoolVar(&config.UseBlocks, "blocks", false, "Use Unicode block characters for higher resolution")
 	flag.BoolVar(&config.Dither, "d", false, "Apply Floyd-Steinberg dithering for smoother gradients")
 	flag.BoolVar(&config.Dither, "dither", false, "Apply Floyd-Steinberg dithering for smoother gradients")
-	flag.StringVar(&colorMode, "color", "", "Enable color output: '256' for 256-color, '24bit' for truecolor")
+	flag.StringVar(&config.DitherAlgorithm, "dither-algo", "", "Dithering algorithm for the dither and braille modes, implies -d (default 'floyd-steinberg'; see -help for the list)")
+	flag.BoolVar(&config.Serpentine, "serpentine", false, "Scan alternate rows right to left with error-diffusion dithering (dither and braille modes)")
+	flag.StringVar(&colorMode, "color", "", "Enable color output: '8' or '16' for basic ANSI, '256' for 256-color, '24bit' for truecolor")
+	flag.StringVar(&colorMetric, "color-metric", "oklab", "Palette color matching: 'oklab' (perceptual) or 'legacy'")
+	flag.BoolVar(&config.SystemColors, "system-colors", false, "Let 256-color output also use the 16 theme-dependent system colors")
+	flag.StringVar(&palette, "palette", "", "Custom output palette as comma-separated hex colors, e.g. '#000,#f80,#fff' (requires -color)")
+	flag.BoolVar(&config.ColorBackground, "color-bg", false, "Apply color to the cell background instead of the character (requires -color; not for halfblock, quadrant or sextant)")
+	flag.StringVar(&config.CellGlyph, "cell-glyph", " ", "Character drawn in every cell with -color-bg")
 	flag.BoolVar(&config.Preview, "p", false, "Show original image inline (instead of ASCII)")
 	flag.BoolVar(&config.Preview, "preview", false, "Show original image inline (instead of ASCII)")
 	flag.StringVar(&config.PreviewMode, "preview-mode", "auto", "Preview mode: 'auto', 'terminal', or 'system'")
 	flag.BoolVar(&config.NoSplit, "no-split", false, "Disable split view (show ASCII only)")
+	flag.BoolVar(&config.KittyPlaceholders, "kitty-placeholders", false, "Draw Kitty previews as Unicode placeholder cells that scroll like text (always on inside tmux)")
+	flag.StringVar(&crop, "crop", "", "Render only the region x,y,w,h, in pixels or percentages like '0,0,50%,50%'")
+	flag.StringVar(&gravity, "gravity", "northwest", "Corner, edge or 'center' that -crop offsets are measured from, e.g. 'center', 'southeast'")
+	flag.Float64Var(&config.Rotate, "rotate", 0, "Rotate clockwise by 90, 180, 270 or any angle in degrees")
+	flag.StringVar(&config.Flip, "flip", "", "Mirror the image: 'h' (left to right) or 'v' (top to bottom)")
+	flag.BoolVar(&config.NoAutoOrient, "no-auto-orient", false, "Ignore the EXIF orientation of JPEG and TIFF images")
+	flag.BoolVar(&probe, "probe", false, "Query the terminal and print which inline image protocols it supports")
 	flag.BoolVar(&showVersion, "version", false, "Show version information")
 	
 	flag.Usage = func() {
@@ -43,6 +87,7 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Show images side-by-side with ASCII art. Reads from stdin if no file specified.\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nDithering algorithms: %s\n", strings.Join(render.DitherAlgorithms(), ", "))
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  %s image.jpg                           # Split view: image + ASCII\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -w 40 image.png                     # Split view with custom width\n", os.Args[0])
@@ -50,16 +95,43 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  %s -c 1.5 image.jpg                    # Split view with contrast\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -b image.jpg                        # Split view with blocks\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -d image.jpg                        # Split view with dithering\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -mode blocks image.jpg              # Select a rendering mode by name\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -charset detailed image.jpg         # Use the 70-character ramp\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -crop 0,0,50%%,50%% screenshot.png   # Top-left quarter only\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -resample lanczos3 photo.jpg        # Sharp, alias-free downscaling\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -color 24bit -blank-transparent logo.png # Transparent logo on any theme\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -linear -luma perceptual image.jpg  # Gamma-correct brightness\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -mode edges diagram.png             # Line art from edge detection\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -color 24bit image.jpg              # Split view with color\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -b -d -color 24bit -c 1.3 image.jpg # Ultimate split view\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -color 24bit -color-bg image.jpg    # Solid colored cells\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -color 16 image.jpg                 # Basic ANSI colors (Linux console, CI logs)\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -d -color 256 image.jpg             # Color error-diffusion dithering\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -dither-algo bayer4 image.jpg       # Ordered dithering, stable across frames\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -p image.jpg                        # Image only (no ASCII)\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -p -preview-mode system image.jpg   # External viewer\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -kitty-placeholders image.jpg      # Kitty image that scrolls like text\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -probe                              # Show what inline images the terminal supports\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  cat image.jpg | %s                     # Pipe mode (ASCII only)\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -no-split image.jpg > ascii.txt     # Save ASCII to file\n", os.Args[0])
 	}
 	
 	flag.Parse()
 	
+	// Without an explicit size, terminal output fits the terminal
+	config.AutoSize = true
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "w", "width", "h", "height":
+			config.AutoSize = false
+		}
+	})
+	
+	// Choosing a dithering algorithm turns dithering on
+	if config.DitherAlgorithm != "" {
+		config.Dither = true
+	}
+	
 	// Handle version flag
 	if showVersion {
 		fmt.Printf("TIV (Terminal Image Viewer) %s\n", Version)
@@ -68,6 +140,20 @@ func main() {
 		return
 	}
 	
+	// Handle probe flag
+	if probe {
+		printCapabilities(os.Stdout, terminalCapabilities())
+		return
+	}
+	
+	// Handle list-modes flag
+	if listModes {
+		for _, mode := range render.Modes() {
+			fmt.Printf("%-10s %s\n", mode.Name, mode.Description)
+		}
+		return
+	}
+	
 	// Parse and validate inputs
 	reader, filename, err := parseInputSource()
 	if err != nil {
@@ -75,12 +161,72 @@ func main() {
 		os.Exit(1)
 	}
 	
+	// Parse color mode
+	config.Color, err = parseColorMode(colorMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	config.ColorMetric, err = parseColorMetric(colorMetric)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	config.Luma, err = parseLuma(lumaModel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	config.Background, err = render.ParseBackground(background)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", ValidationError{Field: "bg", Value: background, Message: "must be 'terminal', 'checkerboard', or a hex color"})
+		os.Exit(1)
+	}
+	config.Fit, err = render.ParseFit(fitMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", ValidationError{Field: "fit", Value: fitMode, Message: "must be one of: contain, cover, fill, none"})
+		os.Exit(1)
+	}
+	if crop != "" {
+		config.Crop, err = render.ParseCrop(crop)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", ValidationError{Field: "crop", Value: crop, Message: "must be x,y,w,h in pixels or percentages, with a non-zero size"})
+			os.Exit(1)
+		}
+	}
+	config.Crop.Gravity, err = render.ParseGravity(gravity)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", ValidationError{Field: "gravity", Value: gravity, Message: "must be one of: northwest, north, northeast, west, center, east, southwest, south, southeast"})
+		os.Exit(1)
+	}
+	if palette != "" {
+		config.Palette, err = render.ParsePalette(palette)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", ValidationError{Field: "palette", Value: palette, Message: err.Error()})
+			os.Exit(1)
+		}
+	}
+	
 	// Validate configuration
 	if err := validateConfig(&config); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 	
+	// Match the proportions of the terminal font we are drawing with
+	if config.CellAspect == 0 && isTerminal(os.Stdout) {
+		if aspect, ok := detectCellAspect(); ok {
+			config.CellAspect = aspect
+		}
+	}
+	
+	// Build the character ramp
+	config.Ramp, err = loadRamp(charset, calibrateFont, blockOutput(config))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	
 	// Validate image file if provided
 	if filename != "" {
 		if err := validateImageFile(filename); err != nil {
@@ -96,9 +242,6 @@ func main() {
 		os.Exit(1)
 	}
 	
-	// Parse color mode
-	config.Color = parseColorMode(colorMode)
-	
 	// Determine operation mode and execute
 	if filename != "" && config.Preview {
 		// Preview mode: show image only
@@ -131,20 +274,111 @@ func parseInputSource() (io.Reader, string, error) {
 }
 
 // parseColorMode converts string to ColorMode
-func parseColorMode(colorMode string) ColorMode {
+func parseColorMode(colorMode string) (render.ColorMode, error) {
 	switch colorMode {
+	case "", "none":
+		return render.ColorNone, nil
+	case "8":
+		return render.Color8, nil
+	case "16":
+		return render.Color16, nil
 	case "256":
-		return Color256
+		return render.Color256, nil
 	case "24bit", "truecolor":
-		return Color24bit
+		return render.Color24bit, nil
+	default:
+		return render.ColorNone, ValidationError{
+			Field:   "color",
+			Value:   colorMode,
+			Message: "must be one of: 8, 16, 256, 24bit, truecolor",
+		}
+	}
+}
+
+// parseColorMetric converts string to ColorMetric
+func parseColorMetric(metric string) (render.ColorMetric, error) {
+	switch metric {
+	case "oklab":
+		return render.ColorMetricOKLab, nil
+	case "legacy":
+		return render.ColorMetricLegacy, nil
+	default:
+		return render.ColorMetricOKLab, ValidationError{
+			Field:   "color-metric",
+			Value:   metric,
+			Message: "must be one of: oklab, legacy",
+		}
+	}
+}
+
+// parseLuma converts string to LumaModel
+func parseLuma(model string) (render.LumaModel, error) {
+	switch model {
+	case "601":
+		return render.LumaRec601, nil
+	case "709":
+		return render.LumaRec709, nil
+	case "perceptual":
+		return render.LumaPerceptual, nil
 	default:
-		return ColorNone
+		return render.LumaRec601, ValidationError{
+			Field:   "luma",
+			Value:   model,
+			Message: "must be one of: 601, 709, perceptual",
+		}
 	}
 }
 
+// blockOutput reports whether config draws Unicode block characters, whose
+// ramp defaults to the blocks preset rather than the standard one
+func blockOutput(config Config) bool {
+	mode := render.New(config.Options).Options().Mode
+	return mode == render.ModeBlocks || (mode == render.ModeDither && config.UseBlocks)
+}
+
+// loadRamp resolves the -charset and -calibrate-font flags to a character
+// ramp, starting from the blocks preset for block output
+func loadRamp(charset, fontFile string, blocks bool) (render.Ramp, error) {
+	var ramp render.Ramp
+	if charset == "" && blocks {
+		ramp, _ = render.PresetRamp("blocks")
+	}
+	if charset != "" {
+		var err error
+		ramp, err = render.ParseRamp(charset)
+		if err != nil {
+			return ramp, ValidationError{
+				Field:   "charset",
+				Value:   charset,
+				Message: fmt.Sprintf("must be a preset (%s) or at least 2 characters", strings.Join(render.RampPresets(), ", ")),
+			}
+		}
+	}
+	
+	if fontFile == "" {
+		return ramp, nil
+	}
+	
+	data, err := os.ReadFile(fontFile)
+	if err != nil {
+		return ramp, friendlyError(err, "reading font")
+	}
+	parsed, err := opentype.Parse(data)
+	if err != nil {
+		return ramp, ImageError{Type: "Invalid font file", Filename: fontFile, Err: err}
+	}
+	face, err := opentype.NewFace(parsed, &opentype.FaceOptions{Size: 48, DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		return ramp, ImageError{Type: "Invalid font file", Filename: fontFile, Err: err}
+	}
+	defer face.Close()
+	
+	return render.CalibrateRamp(ramp, face)
+}
+
 // handlePreviewMode processes preview-only mode
 func handlePreviewMode(filename string, mode PreviewMode, reader io.Reader, config Config) {
-	if err := showImagePreview(filename, mode); err != nil {
+	if err := showImagePreview(filename, mode, config.previewOptions()); err != nil {
 		// Fallback to ASCII if preview fails
 		fmt.Fprintf(os.Stderr, "Image preview not supported, showing ASCII conversion...\n")
 		handleASCIIMode(reader, config)
@@ -154,10 +388,11 @@ func handlePreviewMode(filename string, mode PreviewMode, reader io.Reader, conf
 // handleSplitViewMode processes split view mode (default for files)
 func handleSplitViewMode(filename string, reader io.Reader, config Config) {
 	// Adjust config for split view dimensions
-	termWidth, _ := getTerminalSize()
+	termWidth, termHeight := getTerminalSize()
 	splitConfig := config
-	if splitConfig.Width == 80 { // Only adjust default width
+	if splitConfig.AutoSize { // Only adjust default size
 		splitConfig.Width = termWidth/2 - 1
+		splitConfig.Height = termHeight - 1
 	}
 	
 	// Generate ASCII for right side
@@ -169,7 +404,7 @@ func handleSplitViewMode(filename string, reader io.Reader, config Config) {
 	
 	// Show split view
 	mode := parsePreviewMode(splitConfig.PreviewMode)
-	if err := showSplitView(filename, asciiArt, mode); err != nil {
+	if err := showSplitView(filename, asciiArt, mode, splitConfig.previewOptions()); err != nil {
 		fmt.Fprintf(os.Stderr, "Error showing split view: %v\n", err)
 		os.Exit(1)
 	}
@@ -177,6 +412,13 @@ func handleSplitViewMode(filename string, reader io.Reader, config Config) {
 
 // handleASCIIMode processes ASCII-only mode
 func handleASCIIMode(reader io.Reader, config Config) {
+	// Leave the last row for the shell prompt
+	if config.AutoSize && isTerminal(os.Stdout) {
+		termWidth, termHeight := getTerminalSize()
+		config.Width = min(termWidth, 1000)
+		config.Height = min(max(termHeight-1, 1), 1000)
+	}
+	
 	if err := processImage(reader, config); err != nil {
 		fmt.Fprintf(os.Stderr, "Error processing image: %v\n", err)
 		os.Exit(1)
@@ -185,33 +427,69 @@ func handleASCIIMode(reader io.Reader, config Config) {
 
 // generateASCII reads an image and generates ASCII art string
 func generateASCII(reader io.Reader, config Config) (string, error) {
-	img, _, err := image.Decode(reader)
+	var result strings.Builder
+	if err := renderImage(&result, reader, config); err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
+
+// processImage reads an image and converts it to ASCII
+func processImage(reader io.Reader, config Config) error {
+	return renderImage(os.Stdout, reader, config)
+}
+
+// transformImage applies -crop, -rotate and -flip, in that order
+func transformImage(img image.Image, config Config) (image.Image, error) {
+	img, err := config.Crop.Apply(img)
 	if err != nil {
-		return "", friendlyError(fmt.Errorf("failed to decode image: %w", err), "image decoding")
+		return nil, ImageError{Type: "Invalid crop", Filename: "input image", Err: err}
 	}
 	
-	// Validate image dimensions
-	if err := validateImageDimensions(img, "input image"); err != nil {
-		return "", err
+	img = render.Rotate(img, config.Rotate)
+	
+	switch config.Flip {
+	case "h":
+		img = render.Flip(img, true)
+	case "v":
+		img = render.Flip(img, false)
+	}
+	return img, nil
+}
+
+// renderImage decodes an image and writes its text art to w
+func renderImage(w io.Writer, reader io.Reader, config Config) error {
+	// Keep the raw bytes so the EXIF orientation can be read after decoding
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return friendlyError(fmt.Errorf("failed to read image: %w", err), "image decoding")
 	}
 	
-	// Use memory-optimized processing for large images
-	processor := NewChunkedProcessor(config)
-	result, err := processor.processLargeImageOptimized(img)
+	img, _, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
-		return "", friendlyError(err, "image processing")
+		return friendlyError(fmt.Errorf("failed to decode image: %w", err), "image decoding")
 	}
 	
-	return result, nil
-}
-
-// processImage reads an image and converts it to ASCII
-func processImage(reader io.Reader, config Config) error {
-	result, err := generateASCII(reader, config)
+	// Turn phone photos and scans upright
+	if !config.NoAutoOrient {
+		img = render.Orient(img, exifOrientation(data))
+	}
+	
+	img, err = transformImage(img, config)
 	if err != nil {
 		return err
 	}
-	fmt.Print(result)
+	
+	// Validate image dimensions
+	if err := validateImageDimensions(img, "input image"); err != nil {
+		return err
+	}
+	
+	// The render package handles memory-optimized processing for large images
+	if err := render.New(config.Options).Render(w, img); err != nil {
+		return friendlyError(err, "image processing")
+	}
+	
 	return nil
 }
 
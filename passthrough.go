@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// multiplexer is a terminal multiplexer tiv may be running inside
+type multiplexer int
+
+const (
+	noMultiplexer multiplexer = iota
+	tmuxMultiplexer
+	screenMultiplexer
+)
+
+// GNU screen drops DCS strings longer than this, so longer output is split
+const screenChunkSize = 768
+
+// detectMultiplexer reports which multiplexer, if any, stdout goes through
+func detectMultiplexer() multiplexer {
+	switch {
+	case os.Getenv("TMUX") != "":
+		return tmuxMultiplexer
+	case os.Getenv("STY") != "":
+		return screenMultiplexer
+	}
+	return noMultiplexer
+}
+
+// wrapPassthrough wraps escape sequences meant for the outer terminal in
+// the multiplexer's DCS passthrough, which tmux (with allow-passthrough on)
+// and screen hand on unchanged instead of swallowing them
+func wrapPassthrough(mux multiplexer, data []byte) []byte {
+	var buf bytes.Buffer
+
+	switch mux {
+	case tmuxMultiplexer:
+		// tmux wants every ESC inside the passthrough doubled
+		buf.WriteString("\033Ptmux;")
+		buf.Write(bytes.ReplaceAll(data, []byte("\033"), []byte("\033\033")))
+		buf.WriteString("\033\\")
+
+	case screenMultiplexer:
+		// screen ends the passthrough at the first ESC \ it sees, so a
+		// chunk ends after any inner ESC that is followed by a backslash,
+		// and the backslash starts the next chunk
+		for len(data) > 0 {
+			n := min(screenChunkSize, len(data))
+			if i := bytes.Index(data[:n], []byte("\033\\")); i >= 0 {
+				n = i + 1
+			}
+			buf.WriteString("\033P")
+			buf.Write(data[:n])
+			buf.WriteString("\033\\")
+			data = data[n:]
+		}
+
+	default:
+		return data
+	}
+
+	return buf.Bytes()
+}
+
+// passthroughWriter wraps every write in the multiplexer's passthrough
+type passthroughWriter struct {
+	w   io.Writer
+	mux multiplexer
+}
+
+// Write implements io.Writer
+func (p passthroughWriter) Write(data []byte) (int, error) {
+	if _, err := p.w.Write(wrapPassthrough(p.mux, data)); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// graphicsWriter returns the writer inline image escapes should go to:
+// stdout, wrapped for passthrough when running inside tmux or screen
+func graphicsWriter() io.Writer {
+	if mux := detectMultiplexer(); mux != noMultiplexer {
+		return passthroughWriter{w: os.Stdout, mux: mux}
+	}
+	return os.Stdout
+}
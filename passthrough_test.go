@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWrapPassthrough(t *testing.T) {
+	a767 := strings.Repeat("a", 767)
+	a768 := strings.Repeat("a", 768)
+
+	tests := []struct {
+		name string
+		mux  multiplexer
+		data string
+		want string
+	}{
+		{
+			name: "no multiplexer",
+			mux:  noMultiplexer,
+			data: "\033_Ga=T;AAAA\033\\",
+			want: "\033_Ga=T;AAAA\033\\",
+		},
+		{
+			name: "tmux doubles every ESC",
+			mux:  tmuxMultiplexer,
+			data: "\033_Ga=T;AAAA\033\\",
+			want: "\033Ptmux;\033\033_Ga=T;AAAA\033\033\\\033\\",
+		},
+		{
+			name: "tmux keeps long data in one passthrough",
+			mux:  tmuxMultiplexer,
+			data: a768 + a768,
+			want: "\033Ptmux;" + a768 + a768 + "\033\\",
+		},
+		{
+			name: "screen short data",
+			mux:  screenMultiplexer,
+			data: "abc",
+			want: "\033Pabc\033\\",
+		},
+		{
+			name: "screen chunk of exactly 768 bytes",
+			mux:  screenMultiplexer,
+			data: a768,
+			want: "\033P" + a768 + "\033\\",
+		},
+		{
+			name: "screen splits at 768 bytes",
+			mux:  screenMultiplexer,
+			data: a768 + a768 + "b",
+			want: "\033P" + a768 + "\033\\" + "\033P" + a768 + "\033\\" + "\033Pb\033\\",
+		},
+		{
+			name: "screen splits an inner ST",
+			mux:  screenMultiplexer,
+			data: "\033_Ga=T;AAAA\033\\",
+			want: "\033P\033_Ga=T;AAAA\033\033\\" + "\033P\\\033\\",
+		},
+		{
+			name: "screen splits every inner ST",
+			mux:  screenMultiplexer,
+			data: "\033[>0q\033_Gi=1\033\\\033P>|x\033\\\033[c",
+			want: "\033P\033[>0q\033_Gi=1\033\033\\" + "\033P\\\033P>|x\033\033\\" + "\033P\\\033[c\033\\",
+		},
+		{
+			name: "screen inner ST across the chunk boundary",
+			mux:  screenMultiplexer,
+			data: a767 + "\033\\b",
+			want: "\033P" + a767 + "\033\033\\" + "\033P\\b\033\\",
+		},
+		{
+			name: "screen inner ST just before the chunk boundary",
+			mux:  screenMultiplexer,
+			data: a767[1:] + "\033\\b",
+			want: "\033P" + a767[1:] + "\033\033\\" + "\033P\\b\033\\",
+		},
+		{
+			name: "screen ESC without a backslash stays put",
+			mux:  screenMultiplexer,
+			data: "\033[c",
+			want: "\033P\033[c\033\\",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := string(wrapPassthrough(tt.mux, []byte(tt.data))); got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWrapPassthroughScreenChunks(t *testing.T) {
+	// Kitty commands longer than a screen chunk, each ending in an ST
+	var data bytes.Buffer
+	for i := 0; i < 5; i++ {
+		data.WriteString("\033_Gm=1;")
+		data.WriteString(strings.Repeat("QUFB", 300))
+		data.WriteString("\033\\")
+	}
+
+	out := wrapPassthrough(screenMultiplexer, data.Bytes())
+
+	var rebuilt []byte
+	for len(out) > 0 {
+		if !bytes.HasPrefix(out, []byte("\033P")) {
+			t.Fatalf("chunk does not start with DCS: %q", out[:min(len(out), 16)])
+		}
+		end := bytes.Index(out, []byte("\033\\"))
+		if end < 0 {
+			t.Fatalf("unterminated chunk: %q", out)
+		}
+
+		// screen ends the chunk at its first ESC \, which must be ours
+		chunk := out[2:end]
+		if len(chunk) > screenChunkSize {
+			t.Errorf("chunk of %d bytes exceeds %d", len(chunk), screenChunkSize)
+		}
+		rebuilt = append(rebuilt, chunk...)
+		out = out[end+2:]
+	}
+
+	if !bytes.Equal(rebuilt, data.Bytes()) {
+		t.Error("chunks do not reassemble to the data")
+	}
+}
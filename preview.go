@@ -1,13 +1,24 @@
 package main
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
+	"image"
+	"image/png"
 	"io"
+	"math"
+	"math/rand"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/e6a5/tiv/kitty"
+	"github.com/e6a5/tiv/render"
+	"github.com/e6a5/tiv/sixel"
+	"golang.org/x/image/draw"
 )
 
 // PreviewMode represents different preview modes
@@ -19,17 +30,36 @@ const (
 	PreviewSystem
 )
 
-// showTerminalPreview displays an image directly in the terminal using various protocols
-func showTerminalPreview(filename string, maxWidth, maxHeight int) error {
+// previewOptions holds the CLI options that affect inline image previews
+type previewOptions struct {
+	placeholders bool    // Draw Kitty images as Unicode placeholder cells
+	autoOrient   bool    // Turn images upright according to their EXIF orientation
+	cellAspect   float64 // Cell width divided by height, as for the ASCII side (detected if 0)
+}
+
+// orientation returns the EXIF orientation to apply to image file data,
+// which is OrientationNormal when auto-orientation is off
+func (o previewOptions) orientation(data []byte) render.Orientation {
+	if !o.autoOrient {
+		return render.OrientationNormal
+	}
+	return exifOrientation(data)
+}
+
+// showTerminalPreview displays an image directly in the terminal using various protocols.
+func showTerminalPreview(filename string, maxWidth, maxHeight int, opts previewOptions) error {
 	// Try protocols in order of preference
-	protocols := []func(string, int, int) error{
+	protocols := []func(string, int, int, previewOptions) error{
 		tryKittyProtocol,
 		tryITermProtocol, 
 		trySixelProtocol,
 	}
+	if opts.placeholders {
+		protocols[0] = tryKittyPlaceholders
+	}
 	
 	for _, protocol := range protocols {
-		if err := protocol(filename, maxWidth, maxHeight); err == nil {
+		if err := protocol(filename, maxWidth, maxHeight, opts); err == nil {
 			return nil
 		}
 	}
@@ -38,73 +68,259 @@ func showTerminalPreview(filename string, maxWidth, maxHeight int) error {
 }
 
 // tryKittyProtocol attempts to display image using Kitty terminal protocol
-func tryKittyProtocol(filename string, maxWidth, maxHeight int) error {
+func tryKittyProtocol(filename string, maxWidth, maxHeight int, opts previewOptions) error {
 	// Simple terminal detection
 	if !isKittyCompatible() {
 		return fmt.Errorf("not a Kitty-compatible terminal")
 	}
 	
-	encoded, err := encodeImageFile(filename)
+	data, err := os.ReadFile(filename)
 	if err != nil {
 		return err
 	}
 	
-	// Send Kitty graphics protocol
-	fmt.Printf("\033_Ga=T,f=100")
-	if maxWidth > 0 {
-		fmt.Printf(",c=%d", maxWidth)
+	// Inside tmux an image drawn at the cursor is wiped by the next pane
+	// redraw, so draw it with placeholder cells, which tmux keeps as text
+	if detectMultiplexer() == tmuxMultiplexer {
+		return showKittyPlaceholders(data, maxWidth, maxHeight, opts)
 	}
-	if maxHeight > 0 {
-		fmt.Printf(",r=%d", maxHeight)
+	
+	if err := sendKittyImage(graphicsWriter(), data, kitty.Options{Columns: maxWidth, Rows: maxHeight}, opts.orientation(data)); err != nil {
+		return err
 	}
-	fmt.Printf(";%s\033\\\n", encoded)
+	fmt.Println()
 	
 	return nil
 }
 
+// sendKittyImage transmits image file data with the Kitty protocol. PNG
+// files are sent as they are; anything else is decoded, turned by
+// orientation and transcoded to PNG, which is the only file format Kitty
+// reads.
+func sendKittyImage(w io.Writer, data []byte, opts kitty.Options, orientation render.Orientation) error {
+	if bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")) {
+		return kitty.EncodePNG(w, data, opts)
+	}
+	
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	return kitty.Encode(w, render.Orient(img, orientation), opts)
+}
+
+// tryKittyPlaceholders attempts to display image using Kitty Unicode
+// placeholders, which scroll and redraw like text
+func tryKittyPlaceholders(filename string, maxWidth, maxHeight int, opts previewOptions) error {
+	if !isKittyCompatible() {
+		return fmt.Errorf("not a Kitty-compatible terminal")
+	}
+	
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	
+	return showKittyPlaceholders(data, maxWidth, maxHeight, opts)
+}
+
+// showKittyPlaceholders displays image file data as Kitty placeholder cells
+func showKittyPlaceholders(data []byte, maxWidth, maxHeight int, opts previewOptions) error {
+	lines, err := sendKittyPlaceholders(data, maxWidth, maxHeight, opts)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	
+	return nil
+}
+
+// sendKittyPlaceholders transmits image file data as a virtual placement
+// that fits in maxWidth x maxHeight cells and returns the lines of
+// placeholder cells that show it. The image appears wherever the lines are
+// printed, so no cursor positioning is needed.
+func sendKittyPlaceholders(data []byte, maxWidth, maxHeight int, preview previewOptions) ([]string, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	
+	// Orientations from transpose on swap width and height
+	orientation := preview.orientation(data)
+	width, height := cfg.Width, cfg.Height
+	if orientation >= render.OrientationTranspose {
+		width, height = height, width
+	}
+	cols, rows := fitCells(width, height, maxWidth, maxHeight, preview.cellAspect)
+	
+	id := newImageID()
+	opts := kitty.Options{ID: id, Columns: cols, Rows: rows, Placeholder: true}
+	if err := sendKittyImage(graphicsWriter(), data, opts, orientation); err != nil {
+		return nil, err
+	}
+	
+	return kitty.Placeholders(id, 0, cols, rows)
+}
+
+// fitCells returns the largest cell size, up to the placeholder limit, that
+// fits a width x height pixel image in maxWidth x maxHeight cells while
+// keeping its aspect ratio in cells of cellAspect, which is detected when
+// zero. A non-positive limit leaves that side unlimited.
+func fitCells(width, height, maxWidth, maxHeight int, cellAspect float64) (cols, rows int) {
+	if cellAspect == 0 {
+		var ok bool
+		if cellAspect, ok = detectCellAspect(); !ok {
+			cellAspect = render.DefaultCellAspect
+		}
+	}
+	
+	// Rows per column that keep the image undistorted
+	aspect := float64(height) / float64(width) * cellAspect
+	
+	// Without a width limit, use the image's natural size
+	if maxWidth <= 0 {
+		cellWidth, _, ok := cellPixelSize()
+		if !ok {
+			cellWidth = defaultCellWidth
+		}
+		maxWidth = int(math.Ceil(float64(width) / cellWidth))
+	}
+	maxWidth = min(maxWidth, kitty.MaxPlaceholderCells)
+	if maxHeight <= 0 || maxHeight > kitty.MaxPlaceholderCells {
+		maxHeight = kitty.MaxPlaceholderCells
+	}
+	
+	cols = maxWidth
+	rows = int(math.Round(float64(cols) * aspect))
+	if rows > maxHeight {
+		rows = maxHeight
+		cols = int(math.Round(float64(rows) / aspect))
+	}
+	return max(1, cols), max(1, rows)
+}
+
+// newImageID picks a random Kitty image ID that fits in a 24-bit color, so
+// that images shown by separate runs do not replace each other
+func newImageID() uint32 {
+	return 1 + rand.Uint32()%(1<<24-1)
+}
+
 // tryITermProtocol attempts to display image using iTerm2 inline protocol
-func tryITermProtocol(filename string, maxWidth, maxHeight int) error {
+func tryITermProtocol(filename string, maxWidth, maxHeight int, opts previewOptions) error {
 	if !isITermCompatible() {
 		return fmt.Errorf("not an iTerm2-compatible terminal")
 	}
 	
-	encoded, err := encodeImageFile(filename)
+	encoded, err := encodeImageFile(filename, opts)
 	if err != nil {
 		return err
 	}
 	
 	// Send iTerm2 inline image protocol
-	fmt.Printf("\033]1337;File=inline=1")
+	var seq strings.Builder
+	seq.WriteString("\033]1337;File=inline=1")
 	if maxWidth > 0 {
-		fmt.Printf(";width=%dpx", maxWidth*8)
+		fmt.Fprintf(&seq, ";width=%dpx", maxWidth*8)
 	}
 	if maxHeight > 0 {
-		fmt.Printf(";height=%dpx", maxHeight*16)
+		fmt.Fprintf(&seq, ";height=%dpx", maxHeight*16)
+	}
+	fmt.Fprintf(&seq, ":%s\007", encoded)
+	
+	if _, err := io.WriteString(graphicsWriter(), seq.String()); err != nil {
+		return err
 	}
-	fmt.Printf(":%s\007\n", encoded)
+	fmt.Println()
 	
 	return nil
 }
 
 // trySixelProtocol attempts to display image using Sixel protocol
-func trySixelProtocol(filename string, maxWidth, maxHeight int) error {
+func trySixelProtocol(filename string, maxWidth, maxHeight int, preview previewOptions) error {
 	if !isSixelCompatible() {
 		return fmt.Errorf("terminal does not support sixel")
 	}
 	
-	// Try img2sixel command
-	if _, err := exec.LookPath("img2sixel"); err == nil {
-		cmd := exec.Command("img2sixel", "-w", fmt.Sprintf("%d", maxWidth*8), filename)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		return cmd.Run()
+	img, err := decodeImageFile(filename, preview)
+	if err != nil {
+		return err
+	}
+	
+	// Sixel images are sized in pixels, so convert the cell box
+	cellWidth, cellHeight, ok := cellPixelSize()
+	if !ok {
+		cellWidth, cellHeight = defaultCellWidth, defaultCellHeight
+	}
+	boxWidth, boxHeight := int(float64(maxWidth)*cellWidth), int(float64(maxHeight)*cellHeight)
+	
+	// Stay within the image size and color registers the terminal reports
+	caps := terminalCapabilities()
+	if caps.SixelMaxWidth > 0 && caps.SixelMaxHeight > 0 {
+		boxWidth = min(boxWidth, caps.SixelMaxWidth)
+		if boxHeight <= 0 || boxHeight > caps.SixelMaxHeight {
+			boxHeight = caps.SixelMaxHeight
+		}
+	}
+	img = scaleToBox(img, boxWidth, boxHeight)
+	
+	opts := sixel.Options{Dither: true}
+	if caps.SixelColors >= 2 && caps.SixelColors < sixel.DefaultColors {
+		opts.Colors = caps.SixelColors
+	}
+	
+	if err := sixel.Encode(graphicsWriter(), img, opts); err != nil {
+		return err
+	}
+	fmt.Println()
+	
+	return nil
+}
+
+// Cell size in pixels assumed when the terminal does not report it
+const (
+	defaultCellWidth  = 8
+	defaultCellHeight = 16
+)
+
+// scaleToBox resizes img to the largest size that fits in a width x height
+// pixel box while keeping its aspect ratio. A non-positive height leaves the
+// height unlimited.
+func scaleToBox(img image.Image, width, height int) image.Image {
+	bounds := img.Bounds()
+	if width <= 0 {
+		return img
+	}
+	
+	scale := float64(width) / float64(bounds.Dx())
+	if height > 0 {
+		scale = math.Min(scale, float64(height)/float64(bounds.Dy()))
 	}
+	w := max(1, int(float64(bounds.Dx())*scale))
+	h := max(1, int(float64(bounds.Dy())*scale))
 	
-	return fmt.Errorf("img2sixel not available")
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
 }
 
-// Terminal compatibility checks - simplified
+// Terminal compatibility checks, from probing the terminal
 func isKittyCompatible() bool {
+	return terminalCapabilities().Kitty
+}
+
+func isITermCompatible() bool {
+	return terminalCapabilities().ITerm
+}
+
+func isSixelCompatible() bool {
+	return terminalCapabilities().Sixel
+}
+
+// Fallback compatibility checks from the environment, for terminals that do
+// not answer queries
+func envKittyCompatible() bool {
 	term := os.Getenv("TERM")
 	termProgram := os.Getenv("TERM_PROGRAM")
 	
@@ -114,19 +330,11 @@ func isKittyCompatible() bool {
 		   strings.Contains(termProgram, "konsole")
 }
 
-func isITermCompatible() bool {
-	termProgram := os.Getenv("TERM_PROGRAM")
-	
-	compatibleTerms := []string{"iTerm", "WezTerm", "warp", "vscode", "Tabby", "Hyper", "Bobcat"}
-	for _, term := range compatibleTerms {
-		if strings.Contains(termProgram, term) {
-			return true
-		}
-	}
-	return false
+func envITermCompatible() bool {
+	return containsAny(os.Getenv("TERM_PROGRAM"), iTermTerminals)
 }
 
-func isSixelCompatible() bool {
+func envSixelCompatible() bool {
 	term := os.Getenv("TERM")
 	termProgram := os.Getenv("TERM_PROGRAM")
 	
@@ -139,22 +347,48 @@ func isSixelCompatible() bool {
 	return strings.Contains(term, "sixel")
 }
 
-// encodeImageFile reads and base64 encodes an image file
-func encodeImageFile(filename string) (string, error) {
-	file, err := os.Open(filename)
+// encodeImageFile reads and base64 encodes an image file. Files carrying
+// an EXIF orientation are decoded, turned upright unless opts turn that off,
+// and re-encoded as PNG, which has no orientation tag for the terminal to
+// apply on its own.
+func encodeImageFile(filename string, opts previewOptions) (string, error) {
+	content, err := os.ReadFile(filename)
 	if err != nil {
 		return "", err
 	}
-	defer file.Close()
 	
-	content, err := io.ReadAll(file)
-	if err != nil {
-		return "", err
+	if exifOrientation(content) != render.OrientationNormal {
+		img, _, err := image.Decode(bytes.NewReader(content))
+		if err != nil {
+			return "", err
+		}
+		
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, render.Orient(img, opts.orientation(content))); err != nil {
+			return "", err
+		}
+		content = buf.Bytes()
 	}
 	
 	return base64.StdEncoding.EncodeToString(content), nil
 }
 
+// decodeImageFile reads and decodes an image file, turning it upright
+// according to its EXIF orientation unless opts turn that off
+func decodeImageFile(filename string, opts previewOptions) (image.Image, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	
+	return render.Orient(img, opts.orientation(data)), nil
+}
+
 // parsePreviewMode converts string to PreviewMode
 func parsePreviewMode(mode string) PreviewMode {
 	switch mode {
@@ -168,18 +402,18 @@ func parsePreviewMode(mode string) PreviewMode {
 }
 
 // showImagePreview shows an image preview using the best available method
-func showImagePreview(filename string, mode PreviewMode) error {
+func showImagePreview(filename string, mode PreviewMode, opts previewOptions) error {
 	width, height := getTerminalSize()
 	maxWidth := min(width-10, 80)
 	maxHeight := min(height-5, 24)
 	
 	switch mode {
 	case PreviewTerminal:
-		return showTerminalPreview(filename, maxWidth, maxHeight)
+		return showTerminalPreview(filename, maxWidth, maxHeight, opts)
 	case PreviewSystem:
 		return openSystemViewer(filename)
 	case PreviewAuto:
-		if err := showTerminalPreview(filename, maxWidth, maxHeight); err != nil {
+		if err := showTerminalPreview(filename, maxWidth, maxHeight, opts); err != nil {
 			return openSystemViewer(filename)
 		}
 		return nil
@@ -232,6 +466,12 @@ func getTerminalSize() (int, int) {
 	return width, height
 }
 
+// isTerminal reports whether f is a character device such as a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
 // min returns the minimum of two integers
 func min(a, b int) int {
 	if a < b {
@@ -241,18 +481,31 @@ func min(a, b int) int {
 }
 
 // showSplitView displays the original image and ASCII side by side
-func showSplitView(filename string, asciiArt string, previewMode PreviewMode) error {
+func showSplitView(filename string, asciiArt string, previewMode PreviewMode, opts previewOptions) error {
 	termWidth, termHeight := getTerminalSize()
 	
 	// Calculate equal dimensions for both sides
 	sideWidth := termWidth/2 - 1
 	sideHeight := termHeight - 1
 	
+	// Kitty placeholder cells are text, so both sides can be printed line by
+	// line without clearing the screen; inside tmux this is the only way the
+	// image survives pane redraws
+	if (opts.placeholders || detectMultiplexer() == tmuxMultiplexer) && isKittyCompatible() {
+		if data, err := os.ReadFile(filename); err == nil {
+			if imageLines, err := sendKittyPlaceholders(data, sideWidth, sideHeight, opts); err == nil {
+				showSideBySide(imageLines, asciiArt, sideWidth, sideHeight)
+				return nil
+			}
+		}
+	}
+	
 	// Clear screen and position cursor
 	fmt.Print("\033[2J\033[H")
 	
 	// Try to show image on left side
-	if err := showTerminalPreview(filename, sideWidth, sideHeight); err != nil {
+	opts.placeholders = false
+	if err := showTerminalPreview(filename, sideWidth, sideHeight, opts); err != nil {
 		// Show placeholder box if image preview fails
 		showPlaceholder(filename, sideWidth, sideHeight, asciiArt)
 	}
@@ -266,6 +519,33 @@ func showSplitView(filename string, asciiArt string, previewMode PreviewMode) er
 	return nil
 }
 
+// showSideBySide prints Kitty placeholder lines padded to width cells with
+// the ASCII art beside them
+func showSideBySide(imageLines []string, asciiArt string, width, height int) {
+	asciiLines := strings.Split(strings.TrimSpace(asciiArt), "\n")
+	
+	// Every placeholder line holds the same number of cells
+	imageWidth := 0
+	if len(imageLines) > 0 {
+		imageWidth = strings.Count(imageLines[0], string(kitty.Placeholder))
+	}
+	
+	rows := min(max(len(imageLines), len(asciiLines)), height)
+	for i := 0; i < rows; i++ {
+		left := strings.Repeat(" ", width)
+		if i < len(imageLines) {
+			left = imageLines[i] + strings.Repeat(" ", max(0, width-imageWidth))
+		}
+		
+		right := ""
+		if i < len(asciiLines) {
+			right = truncateCells(asciiLines[i], width)
+		}
+		
+		fmt.Printf("%s %s\n", left, right)
+	}
+}
+
 // showPlaceholder displays a placeholder box when image preview fails
 func showPlaceholder(filename string, width, height int, asciiArt string) {
 	asciiLines := strings.Split(strings.TrimSpace(asciiArt), "\n")
@@ -304,12 +584,40 @@ func showASCIIOnRight(asciiArt string, startCol, width, height int) {
 			break
 		}
 		
-		// Truncate line if too long
-		if len(line) > width {
-			line = line[:width]
+		// Position and print line
+		fmt.Printf("\033[%d;%dH%s", i+1, startCol, truncateCells(line, width))
+	}
+}
+
+// truncateCells cuts a line of rendered art to at most width cells, keeping
+// escape sequences whole and uncounted, and ends it with an SGR reset so a
+// color cut off mid-line does not run on
+func truncateCells(line string, width int) string {
+	var sb strings.Builder
+	cells := 0
+	
+	for i := 0; i < len(line); {
+		// CSI sequences run from ESC [ to a final byte in @ to ~
+		if line[i] == '\033' && i+1 < len(line) && line[i+1] == '[' {
+			end := i + 2
+			for end < len(line) && (line[end] < 0x40 || line[end] > 0x7e) {
+				end++
+			}
+			end = min(end+1, len(line))
+			sb.WriteString(line[i:end])
+			i = end
+			continue
 		}
 		
-		// Position and print line
-		fmt.Printf("\033[%d;%dH%s", i+1, startCol, line)
+		if cells == width {
+			break
+		}
+		_, size := utf8.DecodeRuneInString(line[i:])
+		sb.WriteString(line[i : i+size])
+		cells++
+		i += size
 	}
+	
+	sb.WriteString("\033[0m")
+	return sb.String()
 } 
\ No newline at end of file
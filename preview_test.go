@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestTruncateCells(t *testing.T) {
+	red := "\033[38;2;255;0;0m"
+	tests := []struct {
+		name  string
+		line  string
+		width int
+		want  string
+	}{
+		{"short ASCII", "abc", 5, "abc\033[0m"},
+		{"long ASCII", "abcdef", 3, "abc\033[0m"},
+		{"multi-byte runes", "▀▄█⣿\U0001FB00", 3, "▀▄█\033[0m"},
+		{"escapes are not counted", red + "▀\033[0m" + red + "▄\033[0m", 2, red + "▀\033[0m" + red + "▄\033[0m\033[0m"},
+		{"cut after a color", red + "▀" + red + "▄", 1, red + "▀" + red + "\033[0m"},
+		{"truncated escape", "a\033[38;2", 5, "a\033[38;2\033[0m"},
+		{"zero width", red + "a", 0, red + "\033[0m"},
+	}
+
+	for _, tt := range tests {
+		if got := truncateCells(tt.line, tt.width); got != tt.want {
+			t.Errorf("%s: truncateCells(%q, %d) = %q, want %q", tt.name, tt.line, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestFitCellsCellAspect(t *testing.T) {
+	tests := []struct {
+		cellAspect float64
+		cols, rows int
+	}{
+		{0.5, 40, 10},
+		{1, 40, 20},
+		{0.25, 40, 5},
+	}
+
+	// A 2:1 landscape image in a 40x30 box
+	for _, tt := range tests {
+		if cols, rows := fitCells(200, 100, 40, 30, tt.cellAspect); cols != tt.cols || rows != tt.rows {
+			t.Errorf("fitCells with cell aspect %v = %dx%d, want %dx%d", tt.cellAspect, cols, rows, tt.cols, tt.rows)
+		}
+	}
+}
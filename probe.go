@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Capabilities describes which inline image protocols the terminal supports
+type Capabilities struct {
+	Kitty bool // Kitty graphics protocol
+	ITerm bool // iTerm2 inline images
+	Sixel bool // DEC Sixel graphics
+
+	Version          string // Terminal name and version from XTVERSION, if reported
+	DeviceAttributes []int  // Attributes from the primary device attributes reply
+	SixelColors      int    // Sixel color registers from XTSMGRAPHICS, 0 if unknown
+	SixelMaxWidth    int    // Largest Sixel image in pixels from XTSMGRAPHICS, 0 if unknown
+	SixelMaxHeight   int
+	CellWidth        int // Character cell size in pixels from CSI 16t, 0 if unknown
+	CellHeight       int
+	TextAreaWidth    int // Text area size in pixels from CSI 14t, 0 if unknown
+	TextAreaHeight   int
+	Probed           bool // Whether the terminal answered, rather than guessing from the environment
+}
+
+// Queries sent to the terminal in one go. The Kitty query asks about a 1x1
+// image without storing it, which only Kitty-protocol terminals answer.
+const (
+	kittyQuery         = "\033_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\033\\"
+	versionQuery       = "\033[>0q"
+	sixelColorsQuery   = "\033[?1;1;0S"
+	sixelGeometryQuery = "\033[?2;1;0S"
+	cellSizeQuery      = "\033[16t"
+	textAreaSizeQuery  = "\033[14t"
+)
+
+// Patterns for the replies to the queries above and to the device
+// attributes request queryTerminal appends
+var (
+	kittyReply         = regexp.MustCompile(`\x1b_Gi=31;OK\x1b\\`)
+	versionReply       = regexp.MustCompile(`\x1bP>\|([^\x1b]*)\x1b\\`)
+	sixelColorsReply   = regexp.MustCompile(`\x1b\[\?1;0;(\d+)S`)
+	sixelGeometryReply = regexp.MustCompile(`\x1b\[\?2;0;(\d+);(\d+)S`)
+	attributesReply    = regexp.MustCompile(`\x1b\[\?([\d;]*)c`)
+	cellSizeReply      = regexp.MustCompile(`\x1b\[6;(\d+);(\d+)t`)
+	textAreaSizeReply  = regexp.MustCompile(`\x1b\[4;(\d+);(\d+)t`)
+)
+
+// Attribute in the device attributes reply that advertises Sixel graphics
+const sixelAttribute = 4
+
+// Terminals known to speak the iTerm2 protocol, matched against TERM_PROGRAM
+// or the XTVERSION reply
+var iTermTerminals = []string{"iTerm", "WezTerm", "warp", "vscode", "Tabby", "Hyper", "Bobcat"}
+
+var (
+	capabilitiesOnce sync.Once
+	capabilities     Capabilities
+)
+
+// terminalCapabilities returns the capabilities of the controlling terminal,
+// probing it the first time and reusing the answer afterwards
+func terminalCapabilities() Capabilities {
+	capabilitiesOnce.Do(func() {
+		capabilities = probeTerminal()
+	})
+	return capabilities
+}
+
+// probeTerminal asks the terminal what it supports, falling back to the
+// TERM and TERM_PROGRAM heuristics when it does not answer
+func probeTerminal() Capabilities {
+	reply, err := queryTerminal(kittyQuery + versionQuery + sixelColorsQuery + sixelGeometryQuery + cellSizeQuery + textAreaSizeQuery)
+	if err != nil {
+		return Capabilities{
+			Kitty: envKittyCompatible(),
+			ITerm: envITermCompatible(),
+			Sixel: envSixelCompatible(),
+		}
+	}
+	return parseCapabilities(reply)
+}
+
+// parseCapabilities reads the terminal's replies to the probe queries
+func parseCapabilities(reply string) Capabilities {
+	caps := Capabilities{Probed: true}
+
+	caps.Kitty = kittyReply.MatchString(reply)
+
+	if m := versionReply.FindStringSubmatch(reply); m != nil {
+		caps.Version = m[1]
+	}
+
+	if m := attributesReply.FindStringSubmatch(reply); m != nil {
+		for _, field := range strings.Split(m[1], ";") {
+			if n, err := strconv.Atoi(field); err == nil {
+				caps.DeviceAttributes = append(caps.DeviceAttributes, n)
+				if n == sixelAttribute {
+					caps.Sixel = true
+				}
+			}
+		}
+	}
+
+	if m := sixelColorsReply.FindStringSubmatch(reply); m != nil {
+		caps.SixelColors, _ = strconv.Atoi(m[1])
+	}
+	if m := sixelGeometryReply.FindStringSubmatch(reply); m != nil {
+		caps.SixelMaxWidth, _ = strconv.Atoi(m[1])
+		caps.SixelMaxHeight, _ = strconv.Atoi(m[2])
+	}
+
+	// Both size replies give height before width
+	if m := cellSizeReply.FindStringSubmatch(reply); m != nil {
+		caps.CellHeight, _ = strconv.Atoi(m[1])
+		caps.CellWidth, _ = strconv.Atoi(m[2])
+	}
+	if m := textAreaSizeReply.FindStringSubmatch(reply); m != nil {
+		caps.TextAreaHeight, _ = strconv.Atoi(m[1])
+		caps.TextAreaWidth, _ = strconv.Atoi(m[2])
+	}
+
+	// The iTerm2 protocol has no query, so go by the terminal's name
+	caps.ITerm = containsAny(caps.Version, iTermTerminals) || envITermCompatible()
+
+	return caps
+}
+
+// containsAny reports whether s contains any of substrs
+func containsAny(s string, substrs []string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// printCapabilities writes a report of the detected terminal capabilities
+// for debugging preview problems
+func printCapabilities(w io.Writer, caps Capabilities) {
+	yesNo := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+
+	source := "terminal replies"
+	if !caps.Probed {
+		source = "environment (the terminal did not answer)"
+	}
+	fmt.Fprintf(w, "Detected from:   %s\n", source)
+	if caps.Version != "" {
+		fmt.Fprintf(w, "Terminal:        %s\n", caps.Version)
+	}
+	if len(caps.DeviceAttributes) > 0 {
+		attrs := make([]string, len(caps.DeviceAttributes))
+		for i, n := range caps.DeviceAttributes {
+			attrs[i] = strconv.Itoa(n)
+		}
+		fmt.Fprintf(w, "Attributes:      %s\n", strings.Join(attrs, ";"))
+	}
+	fmt.Fprintf(w, "Kitty graphics:  %s\n", yesNo(caps.Kitty))
+	fmt.Fprintf(w, "iTerm2 images:   %s\n", yesNo(caps.ITerm))
+	fmt.Fprintf(w, "Sixel graphics:  %s\n", yesNo(caps.Sixel))
+	if caps.SixelColors > 0 {
+		fmt.Fprintf(w, "Sixel colors:    %d\n", caps.SixelColors)
+	}
+	if caps.SixelMaxWidth > 0 {
+		fmt.Fprintf(w, "Sixel max size:  %dx%d px\n", caps.SixelMaxWidth, caps.SixelMaxHeight)
+	}
+
+	cols, rows := getTerminalSize()
+	fmt.Fprintf(w, "Terminal size:   %dx%d cells\n", cols, rows)
+	if width, height, ok := cellPixelSize(); ok {
+		fmt.Fprintf(w, "Cell size:       %.4gx%.4g px\n", width, height)
+	} else {
+		fmt.Fprintf(w, "Cell size:       unknown\n")
+	}
+
+	for _, name := range []string{"TERM", "TERM_PROGRAM", "TMUX", "STY"} {
+		fmt.Fprintf(w, "%-17s%s\n", "$"+name+":", os.Getenv(name))
+	}
+}
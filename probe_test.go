@@ -0,0 +1,115 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCapabilities(t *testing.T) {
+	// Keep TERM_PROGRAM from deciding iTerm2 support
+	t.Setenv("TERM_PROGRAM", "")
+
+	tests := []struct {
+		name  string
+		reply string
+		want  Capabilities
+	}{
+		{
+			name:  "kitty",
+			reply: "\x1b_Gi=31;OK\x1b\\\x1bP>|kitty(0.35.2)\x1b\\\x1b[?62;c",
+			want: Capabilities{
+				Kitty:            true,
+				Version:          "kitty(0.35.2)",
+				DeviceAttributes: []int{62},
+				Probed:           true,
+			},
+		},
+		{
+			name:  "xterm",
+			reply: "\x1bP>|XTerm(390)\x1b\\\x1b[?1;0;1024S\x1b[?2;0;1000;1000S\x1b[?63;1;2;4;6;9;15;16;22;28c",
+			want: Capabilities{
+				Sixel:            true,
+				Version:          "XTerm(390)",
+				DeviceAttributes: []int{63, 1, 2, 4, 6, 9, 15, 16, 22, 28},
+				SixelColors:      1024,
+				SixelMaxWidth:    1000,
+				SixelMaxHeight:   1000,
+				Probed:           true,
+			},
+		},
+		{
+			name:  "xterm without sixel",
+			reply: "\x1bP>|XTerm(390)\x1b\\\x1b[?1;3;0S\x1b[?2;3;0;0S\x1b[?64;1;2;6;9;15;16;17;18;21;22;28c",
+			want: Capabilities{
+				Version:          "XTerm(390)",
+				DeviceAttributes: []int{64, 1, 2, 6, 9, 15, 16, 17, 18, 21, 22, 28},
+				Probed:           true,
+			},
+		},
+		{
+			name:  "WezTerm",
+			reply: "\x1b_Gi=31;OK\x1b\\\x1bP>|WezTerm 20240203-110809-5046fc22\x1b\\\x1b[?1;0;256S\x1b[?2;0;2048;2048S\x1b[?65;4;6;18;22c",
+			want: Capabilities{
+				Kitty:            true,
+				ITerm:            true,
+				Sixel:            true,
+				Version:          "WezTerm 20240203-110809-5046fc22",
+				DeviceAttributes: []int{65, 4, 6, 18, 22},
+				SixelColors:      256,
+				SixelMaxWidth:    2048,
+				SixelMaxHeight:   2048,
+				Probed:           true,
+			},
+		},
+		{
+			name:  "cell and text area size",
+			reply: "\x1b[6;20;10t\x1b[4;480;800t\x1b[?1;2c",
+			want: Capabilities{
+				DeviceAttributes: []int{1, 2},
+				CellWidth:        10,
+				CellHeight:       20,
+				TextAreaWidth:    800,
+				TextAreaHeight:   480,
+				Probed:           true,
+			},
+		},
+		{
+			name:  "device attributes only",
+			reply: "\x1b[?1;2c",
+			want: Capabilities{
+				DeviceAttributes: []int{1, 2},
+				Probed:           true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := parseCapabilities(tt.reply); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\ngot  %+v\nwant %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCellSizeFromReplies(t *testing.T) {
+	terminalSize := func() (int, int) { return 80, 24 }
+
+	tests := []struct {
+		name          string
+		reply         string
+		width, height float64
+		ok            bool
+	}{
+		{"cell size", "\x1b[6;20;10t\x1b[4;480;800t\x1b[?1c", 10, 20, true},
+		{"text area only", "\x1b[4;480;800t\x1b[?1c", 10, 20, true},
+		{"malformed cell size", "\x1b[6;20t\x1b[4;480;800t\x1b[?1c", 10, 20, true},
+		{"zero cell size", "\x1b[6;0;0t\x1b[4;480;800t\x1b[?1c", 10, 20, true},
+		{"no size", "\x1b[?1c", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		width, height, ok := cellSizeFromReplies(parseCapabilities(tt.reply), terminalSize)
+		if width != tt.width || height != tt.height || ok != tt.ok {
+			t.Errorf("%s: got %vx%v, %v; want %vx%v, %v", tt.name, width, height, ok, tt.width, tt.height, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package render
+
+import (
+	"image"
+	"io"
+	"strings"
+)
+
+// imageToASCII converts an image to ASCII art
+func imageToASCII(w io.Writer, img image.Image, opts Options) error {
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+	
+	// Calculate output dimensions
+	outWidth, outHeight := opts.outputSize(bounds)
+	
+	for y := 0; y < outHeight; y++ {
+		var row strings.Builder
+		for x := 0; x < outWidth; x++ {
+			if writeBlankCell(&row, img, x, y, outWidth, outHeight, opts) {
+				continue
+			}
+			
+			// Calculate source region to sample
+			startX := float64(x) * float64(width) / float64(outWidth)
+			endX := float64(x+1) * float64(width) / float64(outWidth)
+			startY := float64(y) * float64(height) / float64(outHeight)
+			endY := float64(y+1) * float64(height) / float64(outHeight)
+			
+			// Sample the region (at least the center pixel)
+			minX := int(startX)
+			maxX := int(endX)
+			minY := int(startY)
+			maxY := int(endY)
+			
+			if minX == maxX {
+				maxX = minX + 1
+			}
+			if minY == maxY {
+				maxY = minY + 1
+			}
+			
+			// Clamp to image bounds
+			if minX < 0 { minX = 0 }
+			if maxX >= width { maxX = width - 1 }
+			if minY < 0 { minY = 0 }
+			if maxY >= height { maxY = height - 1 }
+			
+			// A resampled image has exactly one pixel per cell
+			if opts.Resample != "" {
+				minX, minY, maxX, maxY = cellBounds(x, y, outWidth, outHeight, width, height)
+			}
+			
+			// Get color information if needed
+			var r, g, b uint8
+			if opts.Color != ColorNone {
+				r, g, b = sampleRegionColor(img, minX, minY, maxX, maxY, opts)
+			}
+			
+			// Sample for grayscale
+			gray := sampleRegion(img, minX, minY, maxX, maxY, opts)
+			
+			// Apply contrast adjustment
+			adjustedGray := applyContrast(gray, opts.Contrast)
+			
+			// Convert to ASCII character
+			charStr := grayToASCII(adjustedGray, opts.Invert, opts.Ramp)
+			
+			// Apply color if enabled
+			if opts.Color != ColorNone {
+				charStr = colorizeChar(charStr, r, g, b, opts)
+			}
+			
+			row.WriteString(charStr)
+		}
+		row.WriteString("\n")
+		if _, err := io.WriteString(w, row.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// grayToASCII converts a grayscale value (0-255) to a character of ramp
+func grayToASCII(gray int, invert bool, ramp Ramp) string {
+	if invert {
+		gray = 255 - gray
+	}
+	
+	char, _ := ramp.lookup(float64(gray))
+	return char
+}
@@ -0,0 +1,190 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+)
+
+// Background is what transparent pixels are composited onto before they are
+// sampled. The zero Background is BackgroundTerminal.
+type Background struct {
+	kind  backgroundKind
+	color color.RGBA
+}
+
+type backgroundKind int
+
+const (
+	backgroundTerminal backgroundKind = iota
+	backgroundColor
+	backgroundCheckerboard
+)
+
+var (
+	// BackgroundTerminal leaves transparency to the terminal: cells take
+	// the un-premultiplied color of their visible pixels, so transparent
+	// and semi-transparent pixels never darken toward black, and fully
+	// transparent cells can be left blank with Options.BlankTransparent
+	BackgroundTerminal = Background{}
+
+	// BackgroundCheckerboard composites onto mid-gray squares about two
+	// cells wide, like an image editor shows transparency
+	BackgroundCheckerboard = Background{kind: backgroundCheckerboard}
+)
+
+// Checkerboard square colors, readable on both dark and light terminals
+var checkerColors = [2]color.RGBA{
+	{R: 0x66, G: 0x66, B: 0x66, A: 0xff},
+	{R: 0x99, G: 0x99, B: 0x99, A: 0xff},
+}
+
+// SolidBackground composites transparent pixels onto c
+func SolidBackground(c color.Color) Background {
+	r, g, b, _ := c.RGBA()
+	return Background{
+		kind:  backgroundColor,
+		color: color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 0xff},
+	}
+}
+
+// ParseBackground parses "terminal", "checkerboard" or a "#rgb"/"#rrggbb"
+// hex color
+func ParseBackground(spec string) (Background, error) {
+	switch spec {
+	case "terminal":
+		return BackgroundTerminal, nil
+	case "checkerboard":
+		return BackgroundCheckerboard, nil
+	}
+
+	c, ok := parseHexColor(spec)
+	if !ok {
+		return Background{}, fmt.Errorf("invalid background %q", spec)
+	}
+	return SolidBackground(c), nil
+}
+
+// String returns the background in the form accepted by ParseBackground
+func (b Background) String() string {
+	switch b.kind {
+	case backgroundColor:
+		return fmt.Sprintf("#%02x%02x%02x", b.color.R, b.color.G, b.color.B)
+	case backgroundCheckerboard:
+		return "checkerboard"
+	}
+	return "terminal"
+}
+
+// composite wraps img so that its transparent pixels are blended onto
+// opts.Background. Images are returned unchanged for BackgroundTerminal.
+func composite(img image.Image, opts Options) image.Image {
+	if opts.Background.kind == backgroundTerminal {
+		return img
+	}
+
+	// Size checkerboard squares to about two output cells
+	square := 2 * img.Bounds().Dx() / opts.Width
+	if square < 1 {
+		square = 1
+	}
+	return &compositeImage{img: img, bg: opts.Background, square: square}
+}
+
+// compositeImage is an opaque view of an image blended onto a background
+type compositeImage struct {
+	img    image.Image
+	bg     Background
+	square int // Checkerboard square size in pixels
+}
+
+// ColorModel implements image.Image interface
+func (c *compositeImage) ColorModel() color.Model {
+	return color.RGBA64Model
+}
+
+// Bounds implements image.Image interface
+func (c *compositeImage) Bounds() image.Rectangle {
+	return c.img.Bounds()
+}
+
+// At implements image.Image interface
+func (c *compositeImage) At(x, y int) color.Color {
+	r, g, b, a := c.img.At(x, y).RGBA()
+	if a == 0xffff {
+		return color.RGBA64{R: uint16(r), G: uint16(g), B: uint16(b), A: 0xffff}
+	}
+
+	bg := c.bg.color
+	if c.bg.kind == backgroundCheckerboard {
+		bg = checkerColors[(floorDiv(x, c.square)+floorDiv(y, c.square))&1]
+	}
+
+	// Colors are premultiplied, so "over" only adds the uncovered background
+	k := 0xffff - a
+	return color.RGBA64{
+		R: uint16(r + uint32(bg.R)*0x101*k/0xffff),
+		G: uint16(g + uint32(bg.G)*0x101*k/0xffff),
+		B: uint16(b + uint32(bg.B)*0x101*k/0xffff),
+		A: 0xffff,
+	}
+}
+
+// floorDiv divides rounding towards negative infinity, keeping checkerboard
+// squares even across negative coordinates
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+// blankCell reports whether cell (x, y) of an outWidth x outHeight grid is
+// blank as Mode describes: BlankTransparent is set and every pixel the cell
+// covers is fully transparent
+func blankCell(img image.Image, x, y, outWidth, outHeight int, opts Options) bool {
+	if !opts.BlankTransparent {
+		return false
+	}
+
+	bounds := img.Bounds()
+	minX, minY, maxX, maxY := cellBounds(x, y, outWidth, outHeight, bounds.Dx(), bounds.Dy())
+	for py := minY; py <= maxY; py++ {
+		for px := minX; px <= maxX; px++ {
+			if sourceAlpha(img, px, py) != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// writeBlankCell writes a blank cell to row and reports true when cell
+// (x, y) is blank, so renderers can skip sampling it
+func writeBlankCell(row *strings.Builder, img image.Image, x, y, outWidth, outHeight int, opts Options) bool {
+	if !blankCell(img, x, y, outWidth, outHeight, opts) {
+		return false
+	}
+	row.WriteString(" ")
+	return true
+}
+
+// sourceAlpha returns the alpha of the original pixel at (x, y), looking
+// through compositing and chunk cropping
+func sourceAlpha(img image.Image, x, y int) uint32 {
+	switch m := img.(type) {
+	case *compositeImage:
+		return sourceAlpha(m.img, x, y)
+	case *croppedImage:
+		origX, origY := m.rect.Min.X+x, m.rect.Min.Y+y
+		if !image.Pt(origX, origY).In(m.rect) {
+			return 0
+		}
+		return sourceAlpha(m.img, origX, origY)
+	}
+
+	_, _, _, a := img.At(x, y).RGBA()
+	return a
+}
@@ -0,0 +1,77 @@
+package render
+
+import (
+	"image"
+	"io"
+	"strings"
+)
+
+// imageToBlocks converts an image to Unicode block characters for higher resolution
+func imageToBlocks(w io.Writer, img image.Image, opts Options) error {
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+	
+	// Calculate output dimensions
+	outWidth, outHeight := opts.outputSize(bounds)
+	
+	for y := 0; y < outHeight; y++ {
+		var row strings.Builder
+		for x := 0; x < outWidth; x++ {
+			if writeBlankCell(&row, img, x, y, outWidth, outHeight, opts) {
+				continue
+			}
+			
+			// Sample the region for this character
+			startX := float64(x) * float64(width) / float64(outWidth)
+			endX := float64(x+1) * float64(width) / float64(outWidth)
+			startY := float64(y) * float64(height) / float64(outHeight)
+			endY := float64(y+1) * float64(height) / float64(outHeight)
+			
+			minX, maxX, minY, maxY := int(startX), int(endX), int(startY), int(endY)
+			
+			// A resampled image has exactly one pixel per cell
+			if opts.Resample != "" {
+				minX, minY, maxX, maxY = cellBounds(x, y, outWidth, outHeight, width, height)
+			}
+			
+			// Get color information if needed
+			var r, g, b uint8
+			if opts.Color != ColorNone {
+				r, g, b = sampleRegionColor(img, minX, minY, maxX, maxY, opts)
+			}
+			
+			// Get average brightness for this cell
+			gray := sampleRegion(img, minX, minY, maxX, maxY, opts)
+			
+			// Apply contrast adjustment
+			adjustedGray := applyContrast(gray, opts.Contrast)
+			
+			// Convert to block character
+			char := grayToBlock(adjustedGray, opts.Invert, opts.Ramp)
+			
+			// Apply color if enabled
+			if opts.Color != ColorNone {
+				char = colorizeChar(char, r, g, b, opts)
+			}
+			
+			row.WriteString(char)
+		}
+		row.WriteString("\n")
+		if _, err := io.WriteString(w, row.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// grayToBlock converts a grayscale value to a character of ramp, which
+// defaults to the Unicode block ramp
+func grayToBlock(gray int, invert bool, ramp Ramp) string {
+	if invert {
+		gray = 255 - gray
+	}
+	
+	char, _ := ramp.orBlocks().lookup(float64(gray))
+	return char
+}
@@ -0,0 +1,114 @@
+package render
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// Size of the tiled blue-noise threshold matrix
+const blueNoiseSize = 32
+
+var (
+	blueNoiseOnce  sync.Once
+	blueNoiseRanks [][]int
+)
+
+// blueNoiseMatrix returns a blue-noise threshold matrix generated once with
+// the void-and-cluster method, so the output is identical on every run
+func blueNoiseMatrix() [][]float64 {
+	blueNoiseOnce.Do(func() {
+		blueNoiseRanks = voidAndCluster(blueNoiseSize, 1.5)
+	})
+	return rankMatrix(blueNoiseRanks)
+}
+
+// voidAndCluster ranks the cells of a size x size torus so that every
+// prefix of the ranking is an evenly spread (blue noise) point set
+func voidAndCluster(size int, sigma float64) [][]int {
+	cells := size * size
+
+	// Gaussian energy contributed by a point at each toroidal offset
+	kernel := make([]float64, cells)
+	for dy := 0; dy < size; dy++ {
+		for dx := 0; dx < size; dx++ {
+			wx := math.Min(float64(dx), float64(size-dx))
+			wy := math.Min(float64(dy), float64(size-dy))
+			kernel[dy*size+dx] = math.Exp(-(wx*wx + wy*wy) / (2 * sigma * sigma))
+		}
+	}
+
+	points := make([]bool, cells)
+	energy := make([]float64, cells)
+	toggle := func(i int, on bool) {
+		points[i] = on
+		sign := 1.0
+		if !on {
+			sign = -1
+		}
+		px, py := i%size, i/size
+		for j := range energy {
+			dx := (j%size - px + size) % size
+			dy := (j/size - py + size) % size
+			energy[j] += sign * kernel[dy*size+dx]
+		}
+	}
+	// extreme finds the set (or unset) cell with the highest (or lowest) energy
+	extreme := func(set, highest bool) int {
+		best := -1
+		for i, p := range points {
+			if p != set {
+				continue
+			}
+			if best < 0 || (highest && energy[i] > energy[best]) || (!highest && energy[i] < energy[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+
+	// Seed a deterministic random pattern covering a tenth of the cells
+	rng := rand.New(rand.NewSource(1))
+	initial := cells / 10
+	for _, i := range rng.Perm(cells)[:initial] {
+		toggle(i, true)
+	}
+
+	// Move points from the tightest cluster to the largest void until stable
+	for {
+		cluster := extreme(true, true)
+		toggle(cluster, false)
+		void := extreme(false, false)
+		if void == cluster {
+			toggle(cluster, true)
+			break
+		}
+		toggle(void, true)
+	}
+	prototype := append([]bool(nil), points...)
+	prototypeEnergy := append([]float64(nil), energy...)
+
+	ranks := make([]int, cells)
+
+	// Rank the initial points by repeatedly removing the tightest cluster
+	for rank := initial - 1; rank >= 0; rank-- {
+		cluster := extreme(true, true)
+		toggle(cluster, false)
+		ranks[cluster] = rank
+	}
+
+	// Rank the remaining cells by repeatedly filling the largest void
+	copy(points, prototype)
+	copy(energy, prototypeEnergy)
+	for rank := initial; rank < cells; rank++ {
+		void := extreme(false, false)
+		toggle(void, true)
+		ranks[void] = rank
+	}
+
+	matrix := make([][]int, size)
+	for y := range matrix {
+		matrix[y] = ranks[y*size : (y+1)*size]
+	}
+	return matrix
+}
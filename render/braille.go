@@ -0,0 +1,112 @@
+package render
+
+import (
+	"image"
+	"io"
+	"strings"
+)
+
+// Braille patterns start at U+2800; each of the eight dots sets one bit
+const brailleBase = 0x2800
+
+// brailleDots maps a dot position [row][col] within a 2x4 cell to its bit
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// ModeBraille is the name of the 2x4 dots-per-cell renderer
+const ModeBraille = "braille"
+
+func init() {
+	Register(ModeBraille, "Braille dot patterns with 2x4 sub-cell resolution (dithered with Dither)", gridMode{imageToBraille, 2, 4})
+}
+
+// imageToBraille renders each cell as a 2x4 grid of Braille dots. Every dot
+// is thresholded individually, optionally with Floyd-Steinberg dithering,
+// and in color modes the cell takes the average color of its region.
+func imageToBraille(w io.Writer, img image.Image, opts Options) error {
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+	
+	// Calculate output dimensions
+	outWidth, outHeight := opts.outputSize(bounds)
+	
+	// Sample one gray value per dot
+	dotsWide := outWidth * 2
+	dotsHigh := outHeight * 4
+	dots := make([][]float64, dotsHigh)
+	for y := range dots {
+		dots[y] = make([]float64, dotsWide)
+		for x := range dots[y] {
+			minX, minY, maxX, maxY := cellBounds(x, y, dotsWide, dotsHigh, width, height)
+			r, g, b := sampleRegionColor(img, minX, minY, maxX, maxY, opts)
+			gray := applyContrast(luma(r, g, b, opts), opts.Contrast)
+			if opts.Invert {
+				gray = 255 - gray
+			}
+			dots[y][x] = float64(gray)
+		}
+	}
+	
+	// Threshold each dot, dithering when enabled. Invalid algorithms are
+	// rejected by Renderer.Render before we get here.
+	ditherer, _ := newDitherer(opts)
+	lit := make([][]bool, dotsHigh)
+	for y := range dots {
+		lit[y] = make([]bool, dotsWide)
+		for i := range dots[y] {
+			x := i
+			if !opts.Dither {
+				lit[y][x] = dots[y][x] >= 128
+				continue
+			}
+			
+			x = ditherer.scanX(i, y, dotsWide)
+			value := ditherer.bias(dots[y][x], x, y, 255)
+			lit[y][x] = value >= 128
+			
+			var newPixel float64
+			if lit[y][x] {
+				newPixel = 255
+			}
+			ditherer.diffuse(dots, x, y, value-newPixel)
+		}
+	}
+	
+	for y := 0; y < outHeight; y++ {
+		var line strings.Builder
+		for x := 0; x < outWidth; x++ {
+			if writeBlankCell(&line, img, x, y, outWidth, outHeight, opts) {
+				continue
+			}
+			
+			pattern := rune(brailleBase)
+			for row := 0; row < 4; row++ {
+				for col := 0; col < 2; col++ {
+					if lit[y*4+row][x*2+col] {
+						pattern |= brailleDots[row][col]
+					}
+				}
+			}
+			char := string(pattern)
+			
+			// Apply color if enabled
+			if opts.Color != ColorNone {
+				minX, minY, maxX, maxY := cellBounds(x, y, outWidth, outHeight, width, height)
+				r, g, b := sampleRegionColor(img, minX, minY, maxX, maxY, opts)
+				char = colorizeChar(char, r, g, b, opts)
+			}
+			
+			line.WriteString(char)
+		}
+		line.WriteString("\n")
+		if _, err := io.WriteString(w, line.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// memoryLimits defines memory usage constraints
+type memoryLimits struct {
+	MaxPixels      int // Maximum pixels to process at once
+	ChunkSize      int // Size of processing chunks
+	MaxGoroutines  int // Maximum concurrent goroutines
+	BufferSizeKB   int // Buffer size in KB
+}
+
+// getMemoryLimits returns appropriate memory limits based on system resources
+func getMemoryLimits() memoryLimits {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	
+	// Conservative limits based on available memory
+	return memoryLimits{
+		MaxPixels:     10_000_000, // 10 megapixels max per chunk
+		ChunkSize:     1000,       // 1000x1000 pixel chunks
+		MaxGoroutines: runtime.NumCPU(),
+		BufferSizeKB:  1024,       // 1MB buffer
+	}
+}
+
+// chunkedProcessor handles large image processing in chunks
+type chunkedProcessor struct {
+	limits memoryLimits
+	mode   Mode
+	opts   Options
+}
+
+// newChunkedProcessor creates a new chunked processor
+func newChunkedProcessor(mode Mode, opts Options) *chunkedProcessor {
+	return &chunkedProcessor{
+		limits: getMemoryLimits(),
+		mode:   mode,
+		opts:   opts,
+	}
+}
+
+// processLargeImageOptimized processes large images with memory optimization
+func (cp *chunkedProcessor) processLargeImageOptimized(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	imgWidth, imgHeight := bounds.Dx(), bounds.Dy()
+	
+	// Check if image needs chunked processing
+	totalPixels := imgWidth * imgHeight
+	if totalPixels <= cp.limits.MaxPixels {
+		// Small enough to process normally
+		return cp.processRegularImage(w, img)
+	}
+	
+	// Large image - use chunked processing
+	return cp.processImageInChunks(w, img)
+}
+
+// processRegularImage processes smaller images normally
+func (cp *chunkedProcessor) processRegularImage(w io.Writer, img image.Image) error {
+	return cp.mode.Render(w, img, cp.opts)
+}
+
+// processImageInChunks processes large images in smaller chunks. Chunks are
+// rendered one band of rows at a time, and each band's lines are written to
+// w as soon as all of its chunks are done.
+func (cp *chunkedProcessor) processImageInChunks(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	
+	// Calculate chunk dimensions
+	chunkWidth := cp.limits.ChunkSize
+	chunkHeight := cp.limits.ChunkSize
+	
+	// Calculate output dimensions
+	outWidth, outHeight := cp.opts.outputSize(bounds)
+	
+	// Calculate how many chunks we need
+	chunksX := (outWidth + chunkWidth - 1) / chunkWidth
+	chunksY := (outHeight + chunkHeight - 1) / chunkHeight
+	
+	// Use a semaphore to limit concurrent processing
+	semaphore := make(chan struct{}, cp.limits.MaxGoroutines)
+	band := make([]bytes.Buffer, chunksX)
+	errs := make([]error, chunksX)
+	
+	for chunkY := 0; chunkY < chunksY; chunkY++ {
+		var wg sync.WaitGroup
+		
+		// Process the chunks of this band concurrently
+		for chunkX := 0; chunkX < chunksX; chunkX++ {
+			band[chunkX].Reset()
+			wg.Add(1)
+			go func(cx int) {
+				defer wg.Done()
+				semaphore <- struct{}{} // Acquire semaphore
+				defer func() { <-semaphore }() // Release semaphore
+				
+				errs[cx] = cp.processChunk(&band[cx], img, cx, chunkY, chunkWidth, chunkHeight, outWidth, outHeight)
+			}(chunkX)
+		}
+		wg.Wait()
+		
+		for chunkX, err := range errs {
+			if err != nil {
+				return fmt.Errorf("chunk processing error at (%d,%d): %w", chunkX, chunkY, err)
+			}
+		}
+		
+		if err := writeBand(w, band); err != nil {
+			return err
+		}
+	}
+	
+	return nil
+}
+
+// processChunk renders a single chunk of the image to buf
+func (cp *chunkedProcessor) processChunk(buf *bytes.Buffer, img image.Image, chunkX, chunkY, chunkWidth, chunkHeight, totalOutWidth, totalOutHeight int) error {
+	// Calculate the region of output this chunk represents
+	startOutX := chunkX * chunkWidth
+	endOutX := startOutX + chunkWidth
+	if endOutX > totalOutWidth {
+		endOutX = totalOutWidth
+	}
+	
+	startOutY := chunkY * chunkHeight
+	endOutY := startOutY + chunkHeight
+	if endOutY > totalOutHeight {
+		endOutY = totalOutHeight
+	}
+	
+	actualChunkWidth := endOutX - startOutX
+	actualChunkHeight := endOutY - startOutY
+	
+	if actualChunkWidth <= 0 || actualChunkHeight <= 0 {
+		return nil
+	}
+	
+	// Create options for this chunk
+	chunkOpts := cp.opts
+	chunkOpts.Width = actualChunkWidth
+	chunkOpts.Height = actualChunkHeight
+	
+	// Create a sub-image for this region
+	bounds := img.Bounds()
+	imgWidth, imgHeight := bounds.Dx(), bounds.Dy()
+	
+	// Map output coordinates to image coordinates
+	startImgX := int(float64(startOutX) * float64(imgWidth) / float64(totalOutWidth))
+	endImgX := int(float64(endOutX) * float64(imgWidth) / float64(totalOutWidth))
+	startImgY := int(float64(startOutY) * float64(imgHeight) / float64(totalOutHeight))
+	endImgY := int(float64(endOutY) * float64(imgHeight) / float64(totalOutHeight))
+	
+	// Create cropped image
+	cropRect := image.Rect(startImgX, startImgY, endImgX, endImgY)
+	croppedImg := &croppedImage{
+		img:  img,
+		rect: cropRect,
+	}
+	
+	// Process the chunk
+	return cp.mode.Render(buf, croppedImg, chunkOpts)
+}
+
+// writeBand writes the chunks of one band side by side, joining their
+// lines horizontally, and consumes the chunk buffers
+func writeBand(w io.Writer, chunks []bytes.Buffer) error {
+	for {
+		var line []byte
+		done := true
+		for i := range chunks {
+			part, _ := chunks[i].ReadBytes('\n')
+			if len(part) > 0 {
+				done = false
+			}
+			line = append(line, bytes.TrimRight(part, "\r\n")...)
+		}
+		if done {
+			return nil
+		}
+		if len(line) == 0 {
+			continue
+		}
+		
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+}
+
+// croppedImage represents a cropped view of an image
+type croppedImage struct {
+	img  image.Image
+	rect image.Rectangle
+}
+
+// ColorModel implements image.Image interface
+func (c *croppedImage) ColorModel() color.Model {
+	return c.img.ColorModel()
+}
+
+// Bounds implements image.Image interface
+func (c *croppedImage) Bounds() image.Rectangle {
+	return image.Rect(0, 0, c.rect.Dx(), c.rect.Dy())
+}
+
+// At implements image.Image interface
+func (c *croppedImage) At(x, y int) color.Color {
+	// Map coordinates to original image
+	origX := c.rect.Min.X + x
+	origY := c.rect.Min.Y + y
+	
+	// Bounds check
+	if !image.Pt(origX, origY).In(c.rect) {
+		return c.img.ColorModel().Convert(color.Transparent)
+	}
+	
+	return c.img.At(origX, origY)
+}
+
+// Note: forceGC function removed as it was unused in current implementation
+// Memory management is handled automatically by the chunked processor 
\ No newline at end of file
@@ -0,0 +1,42 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestProcessImageInChunks(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 50, 40))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 50; x++ {
+			img.SetGray(x, y, color.Gray{Y: uint8(x * 5)})
+		}
+	}
+
+	opts := New(Options{Width: 25, Height: 10}).Options()
+	mode, _ := Lookup(ModeASCII)
+	cp := &chunkedProcessor{
+		limits: memoryLimits{ChunkSize: 4, MaxGoroutines: 2},
+		mode:   mode,
+		opts:   opts,
+	}
+
+	var chunked strings.Builder
+	if err := cp.processImageInChunks(&chunked, img); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(chunked.String(), "\n"), "\n")
+	if len(lines) != 10 {
+		t.Fatalf("got %d lines, want 10", len(lines))
+	}
+	for i, line := range lines {
+		if n := utf8.RuneCountInString(line); n != 25 {
+			t.Errorf("line %d has %d cells, want 25", i, n)
+		}
+	}
+
+}
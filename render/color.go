@@ -0,0 +1,171 @@
+package render
+
+import "fmt"
+
+// colorizeChar wraps a character with ANSI color codes. With
+// ColorBackground the color fills the cell behind CellGlyph instead.
+func colorizeChar(char string, r, g, b uint8, opts Options) string {
+	if opts.Color == ColorNone {
+		return char
+	}
+	
+	if opts.ColorBackground {
+		glyph := opts.CellGlyph
+		if glyph == "" {
+			glyph = " "
+		}
+		return bgColorCode(r, g, b, opts) + glyph + "\033[0m"
+	}
+	
+	return fgColorCode(r, g, b, opts) + char + "\033[0m"
+}
+
+// colorizeCell wraps a character with ANSI foreground and background color codes
+func colorizeCell(char string, fg, bg [3]uint8, opts Options) string {
+	if opts.Color == ColorNone {
+		return char
+	}
+	
+	return fgColorCode(fg[0], fg[1], fg[2], opts) +
+		bgColorCode(bg[0], bg[1], bg[2], opts) +
+		char + "\033[0m"
+}
+
+// ansi16Palette holds the xterm default RGB values of the 8 standard
+// (0-7) and 8 bright (8-15) ANSI colors
+var ansi16Palette = [16][3]uint8{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// fgColorCode returns the ANSI escape sequence selecting a foreground color
+func fgColorCode(r, g, b uint8, opts Options) string {
+	r, g, b = opts.Palette.snap(r, g, b)
+	
+	switch opts.Color {
+	case Color16, Color8:
+		return fmt.Sprintf("\033[%dm", ansiColorCode(paletteIndex(r, g, b, opts), 30, 90))
+	case Color256:
+		return fmt.Sprintf("\033[38;5;%dm", paletteIndex(r, g, b, opts))
+	case Color24bit:
+		return fmt.Sprintf("\033[38;2;%d;%d;%dm", r, g, b)
+	}
+	return ""
+}
+
+// bgColorCode returns the ANSI escape sequence selecting a background color
+func bgColorCode(r, g, b uint8, opts Options) string {
+	r, g, b = opts.Palette.snap(r, g, b)
+	
+	switch opts.Color {
+	case Color16, Color8:
+		return fmt.Sprintf("\033[%dm", ansiColorCode(paletteIndex(r, g, b, opts), 40, 100))
+	case Color256:
+		return fmt.Sprintf("\033[48;5;%dm", paletteIndex(r, g, b, opts))
+	case Color24bit:
+		return fmt.Sprintf("\033[48;2;%d;%d;%dm", r, g, b)
+	}
+	return ""
+}
+
+// quantizeColor returns the palette index and color of the entry that r, g,
+// b is output as: the custom palette entry if one is set, otherwise the
+// 8/16/256 terminal palette entry. ok is false for unquantized truecolor.
+func quantizeColor(r, g, b uint8, opts Options) (index int, entry [3]uint8, ok bool) {
+	if opts.Palette.lut != nil {
+		index = opts.Palette.lut.lookup(r, g, b)
+		return index, opts.Palette.lut.colors[index], true
+	}
+	
+	switch opts.Color {
+	case Color8, Color16, Color256:
+		index = paletteIndex(r, g, b, opts)
+		return index, xterm256Palette[index], true
+	}
+	return 0, [3]uint8{r, g, b}, false
+}
+
+// paletteIndex returns the index of the palette color closest to r, g, b
+// for the 8, 16 and 256 color modes, using the configured color metric
+func paletteIndex(r, g, b uint8, opts Options) int {
+	if opts.ColorMetric == ColorMetricLegacy {
+		if opts.Color == Color256 {
+			return rgbTo256Color(r, g, b)
+		}
+		return rgbToANSI16(r, g, b, opts.Color)
+	}
+	
+	switch opts.Color {
+	case Color8:
+		return ansi8LUT.lookup(r, g, b)
+	case Color16:
+		return ansi16LUT.lookup(r, g, b)
+	}
+	if opts.SystemColors {
+		return xterm256LUT.lookup(r, g, b)
+	}
+	return xtermFixedLUT.lookup(r, g, b)
+}
+
+// rgbTo256Color converts RGB values to the closest 256-color palette index
+func rgbTo256Color(r, g, b uint8) int {
+	// For colors 16-231: 6x6x6 color cube
+	if r == g && g == b {
+		// Grayscale colors 232-255
+		if r < 8 {
+			return 16
+		}
+		if r > 248 {
+			return 231
+		}
+		return 232 + (int(r)-8)/10
+	}
+	
+	// Map to 6x6x6 color cube (colors 16-231)
+	rIndex := int(r) * 5 / 255
+	gIndex := int(g) * 5 / 255
+	bIndex := int(b) * 5 / 255
+	
+	return 16 + 36*rIndex + 6*gIndex + bIndex
+} 
+
+// rgbToANSI16 returns the index (0-15, or 0-7 in Color8 mode) of the
+// closest basic ANSI color
+func rgbToANSI16(r, g, b uint8, mode ColorMode) int {
+	count := len(ansi16Palette)
+	if mode == Color8 {
+		count = 8
+	}
+	
+	best := 0
+	bestDist := -1
+	for i := 0; i < count; i++ {
+		p := ansi16Palette[i]
+		if dist := redmeanDistance(r, g, b, p[0], p[1], p[2]); bestDist < 0 || dist < bestDist {
+			best = i
+			bestDist = dist
+		}
+	}
+	return best
+}
+
+// ansiColorCode converts a basic color index to its SGR parameter, using
+// base for the standard colors and brightBase for the bright ones
+func ansiColorCode(index, base, brightBase int) int {
+	if index < 8 {
+		return base + index
+	}
+	return brightBase + index - 8
+}
+
+// redmeanDistance is a low-cost perceptual color distance that weights the
+// RGB channels according to the mean red level of the two colors
+func redmeanDistance(r1, g1, b1, r2, g2, b2 uint8) int {
+	rmean := (int(r1) + int(r2)) / 2
+	dr := int(r1) - int(r2)
+	dg := int(g1) - int(g2)
+	db := int(b1) - int(b2)
+	return (((512 + rmean) * dr * dr) >> 8) + 4*dg*dg + (((767 - rmean) * db * db) >> 8)
+}
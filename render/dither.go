@@ -0,0 +1,175 @@
+package render
+
+import (
+	"image"
+	"io"
+	"strings"
+)
+
+// imageToArtWithDithering converts an image to ASCII/blocks with the
+// configured dithering algorithm
+func imageToArtWithDithering(w io.Writer, img image.Image, opts Options) error {
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+	
+	// Calculate output dimensions
+	outWidth, outHeight := opts.outputSize(bounds)
+	
+	// Create buffers for dithering
+	grayBuffer := make([][]float64, outHeight)
+	blank := make([][]bool, outHeight) // Fully transparent cells left blank
+	var colorBuffer [3][][]float64 // Per-channel RGB buffers for color mode
+	
+	for y := range grayBuffer {
+		grayBuffer[y] = make([]float64, outWidth)
+		blank[y] = make([]bool, outWidth)
+	}
+	
+	if opts.Color != ColorNone {
+		for c := range colorBuffer {
+			colorBuffer[c] = make([][]float64, outHeight)
+			for y := range colorBuffer[c] {
+				colorBuffer[c][y] = make([]float64, outWidth)
+			}
+		}
+	}
+	
+	// First pass: fill the buffers with values
+	for y := 0; y < outHeight; y++ {
+		for x := 0; x < outWidth; x++ {
+			// Sample the region for this character
+			startX := float64(x) * float64(width) / float64(outWidth)
+			endX := float64(x+1) * float64(width) / float64(outWidth)
+			startY := float64(y) * float64(height) / float64(outHeight)
+			endY := float64(y+1) * float64(height) / float64(outHeight)
+			
+			minX, maxX, minY, maxY := int(startX), int(endX), int(startY), int(endY)
+			
+			// A resampled image has exactly one pixel per cell
+			if opts.Resample != "" {
+				minX, minY, maxX, maxY = cellBounds(x, y, outWidth, outHeight, width, height)
+			}
+			blank[y][x] = blankCell(img, x, y, outWidth, outHeight, opts)
+			
+			gray := sampleRegion(img, minX, minY, maxX, maxY, opts)
+			adjustedGray := applyContrast(gray, opts.Contrast)
+			grayBuffer[y][x] = float64(adjustedGray)
+			
+			// Store color information if needed
+			if opts.Color != ColorNone {
+				r, g, b := sampleRegionColor(img, minX, minY, maxX, maxY, opts)
+				colorBuffer[0][y][x] = float64(r)
+				colorBuffer[1][y][x] = float64(g)
+				colorBuffer[2][y][x] = float64(b)
+			}
+		}
+	}
+	
+	// Invalid algorithms are rejected by Renderer.Render before we get here
+	ditherer, _ := newDitherer(opts)
+	
+	// Distance between output levels, used to scale ordered dithering
+	levels := len(opts.Ramp.orDefault().chars)
+	if opts.UseBlocks {
+		levels = len(opts.Ramp.orBlocks().chars)
+	}
+	graySpread := 255.0 / float64(levels-1)
+	colorSpread := paletteSpread(opts)
+	
+	// Second pass: apply dithering
+	row := make([]string, outWidth)
+	for y := 0; y < outHeight; y++ {
+		for i := 0; i < outWidth; i++ {
+			x := ditherer.scanX(i, y, outWidth)
+			
+			// Blank cells are not quantized, so they carry no error
+			if blank[y][x] {
+				row[x] = " "
+				continue
+			}
+			
+			// Get current pixel value
+			oldPixel := ditherer.bias(grayBuffer[y][x], x, y, graySpread)
+			
+			// Find closest character and its gray value
+			var newPixel float64
+			var char string
+			
+			if opts.UseBlocks {
+				char, newPixel = findClosestBlock(oldPixel, opts.Invert, opts.Ramp)
+			} else {
+				char, newPixel = findClosestASCII(oldPixel, opts.Invert, opts.Ramp)
+			}
+			
+			// Apply color if enabled, dithering the palette quantization
+			// error when the output is limited to a palette
+			if opts.Color != ColorNone {
+				var rgb [3]uint8
+				for c := range rgb {
+					rgb[c] = clampChannel(ditherer.bias(colorBuffer[c][y][x], x, y, colorSpread))
+				}
+				char = colorizeChar(char, rgb[0], rgb[1], rgb[2], opts)
+				
+				if _, entry, ok := quantizeColor(rgb[0], rgb[1], rgb[2], opts); ok {
+					for c := range rgb {
+						ditherer.diffuse(colorBuffer[c], x, y, float64(rgb[c])-float64(entry[c]))
+					}
+				}
+			}
+			
+			row[x] = char
+			
+			// Calculate quantization error
+			error := oldPixel - newPixel
+			
+			// Distribute error to neighboring pixels
+			ditherer.diffuse(grayBuffer, x, y, error)
+		}
+		var line strings.Builder
+		for _, char := range row {
+			line.WriteString(char)
+		}
+		line.WriteString("\n")
+		if _, err := io.WriteString(w, line.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clampChannel rounds a dithered color channel to the 0-255 range
+func clampChannel(v float64) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
+
+// findClosestASCII finds the closest character of ramp for a grayscale value
+func findClosestASCII(gray float64, invert bool, ramp Ramp) (string, float64) {
+	if invert {
+		gray = 255.0 - gray
+	}
+	
+	// Clamp gray value
+	if gray < 0 { gray = 0 }
+	if gray > 255 { gray = 255 }
+	
+	// Return character and its effective gray value
+	char, effectiveGray := ramp.lookup(gray)
+	if invert {
+		effectiveGray = 255.0 - effectiveGray
+	}
+	
+	return char, effectiveGray
+}
+
+// findClosestBlock finds the closest character of ramp, which defaults to
+// the Unicode block ramp, for a grayscale value
+func findClosestBlock(gray float64, invert bool, ramp Ramp) (string, float64) {
+	return findClosestASCII(gray, invert, ramp.orBlocks())
+}
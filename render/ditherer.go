@@ -0,0 +1,189 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultDitherAlgorithm is used when Options.DitherAlgorithm is empty
+const DefaultDitherAlgorithm = "floyd-steinberg"
+
+// kernelTap spreads weight/divisor of the error to the pixel at (dx, dy)
+type kernelTap struct {
+	dx, dy int
+	weight float64
+}
+
+// diffusionKernel is an error-diffusion pattern for left-to-right scanning
+type diffusionKernel struct {
+	divisor float64
+	taps    []kernelTap
+}
+
+// Error-diffusion kernels by name
+var diffusionKernels = map[string]diffusionKernel{
+	"floyd-steinberg": {16, []kernelTap{
+		{1, 0, 7}, {-1, 1, 3}, {0, 1, 5}, {1, 1, 1},
+	}},
+	// Atkinson deliberately diffuses only 6/8 of the error
+	"atkinson": {8, []kernelTap{
+		{1, 0, 1}, {2, 0, 1}, {-1, 1, 1}, {0, 1, 1}, {1, 1, 1}, {0, 2, 1},
+	}},
+	"jarvis-judice-ninke": {48, []kernelTap{
+		{1, 0, 7}, {2, 0, 5},
+		{-2, 1, 3}, {-1, 1, 5}, {0, 1, 7}, {1, 1, 5}, {2, 1, 3},
+		{-2, 2, 1}, {-1, 2, 3}, {0, 2, 5}, {1, 2, 3}, {2, 2, 1},
+	}},
+	"stucki": {42, []kernelTap{
+		{1, 0, 8}, {2, 0, 4},
+		{-2, 1, 2}, {-1, 1, 4}, {0, 1, 8}, {1, 1, 4}, {2, 1, 2},
+		{-2, 2, 1}, {-1, 2, 2}, {0, 2, 4}, {1, 2, 2}, {2, 2, 1},
+	}},
+	"burkes": {32, []kernelTap{
+		{1, 0, 8}, {2, 0, 4},
+		{-2, 1, 2}, {-1, 1, 4}, {0, 1, 8}, {1, 1, 4}, {2, 1, 2},
+	}},
+	"sierra": {32, []kernelTap{
+		{1, 0, 5}, {2, 0, 3},
+		{-2, 1, 2}, {-1, 1, 4}, {0, 1, 5}, {1, 1, 4}, {2, 1, 2},
+		{-1, 2, 2}, {0, 2, 3}, {1, 2, 2},
+	}},
+	"sierra-two-row": {16, []kernelTap{
+		{1, 0, 4}, {2, 0, 3},
+		{-2, 1, 1}, {-1, 1, 2}, {0, 1, 3}, {1, 1, 2}, {2, 1, 1},
+	}},
+	"sierra-lite": {4, []kernelTap{
+		{1, 0, 2}, {-1, 1, 1}, {0, 1, 1},
+	}},
+}
+
+// Ordered dithering threshold matrices by name; values are in [0, 1)
+var thresholdMatrices = map[string]func() [][]float64{
+	"bayer2":     func() [][]float64 { return bayerMatrix(2) },
+	"bayer4":     func() [][]float64 { return bayerMatrix(4) },
+	"bayer8":     func() [][]float64 { return bayerMatrix(8) },
+	"blue-noise": blueNoiseMatrix,
+}
+
+// DitherAlgorithms returns the names of all dithering algorithms sorted alphabetically
+func DitherAlgorithms() []string {
+	names := make([]string, 0, len(diffusionKernels)+len(thresholdMatrices))
+	for name := range diffusionKernels {
+		names = append(names, name)
+	}
+	for name := range thresholdMatrices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ditherer applies either an error-diffusion kernel or an ordered
+// threshold matrix to a buffer of values
+type ditherer struct {
+	kernel     *diffusionKernel
+	matrix     [][]float64
+	serpentine bool
+}
+
+// newDitherer returns the ditherer for opts.DitherAlgorithm
+func newDitherer(opts Options) (ditherer, error) {
+	name := opts.DitherAlgorithm
+	if name == "" {
+		name = DefaultDitherAlgorithm
+	}
+
+	if kernel, ok := diffusionKernels[name]; ok {
+		return ditherer{kernel: &kernel, serpentine: opts.Serpentine}, nil
+	}
+	if matrix, ok := thresholdMatrices[name]; ok {
+		return ditherer{matrix: matrix()}, nil
+	}
+	return ditherer{}, fmt.Errorf("unknown dither algorithm %q", opts.DitherAlgorithm)
+}
+
+// reversed reports whether row y is scanned right to left
+func (d ditherer) reversed(y int) bool {
+	return d.serpentine && y%2 == 1
+}
+
+// scanX returns the column visited at step i of row y in a row of width cells
+func (d ditherer) scanX(i, y, width int) int {
+	if d.reversed(y) {
+		return width - 1 - i
+	}
+	return i
+}
+
+// bias offsets v by the ordered threshold at (x, y), scaled to spread (the
+// distance between output levels). Error-diffusion ditherers return v as is.
+func (d ditherer) bias(v float64, x, y int, spread float64) float64 {
+	if d.matrix == nil {
+		return v
+	}
+	row := d.matrix[y%len(d.matrix)]
+	return v + (row[x%len(row)]-0.5)*spread
+}
+
+// diffuse distributes the quantization error of buffer[y][x] to its
+// unprocessed neighbors. Ordered ditherers diffuse nothing.
+func (d ditherer) diffuse(buffer [][]float64, x, y int, err float64) {
+	if d.kernel == nil {
+		return
+	}
+
+	height := len(buffer)
+	width := len(buffer[y])
+	direction := 1
+	if d.reversed(y) {
+		direction = -1 // Mirror the kernel when scanning right to left
+	}
+
+	for _, tap := range d.kernel.taps {
+		tx := x + tap.dx*direction
+		ty := y + tap.dy
+		if tx < 0 || tx >= width || ty >= height {
+			continue
+		}
+		buffer[ty][tx] += err * tap.weight / d.kernel.divisor
+	}
+}
+
+// bayerMatrix builds the size x size Bayer threshold matrix (size a power of 2)
+func bayerMatrix(size int) [][]float64 {
+	indices := [][]int{{0}}
+	for n := 1; n < size; n *= 2 {
+		next := make([][]int, 2*n)
+		for y := range next {
+			next[y] = make([]int, 2*n)
+			for x := range next[y] {
+				v := 4 * indices[y%n][x%n]
+				switch {
+				case y < n && x >= n:
+					v += 2
+				case y >= n && x < n:
+					v += 3
+				case y >= n && x >= n:
+					v++
+				}
+				next[y][x] = v
+			}
+		}
+		indices = next
+	}
+
+	return rankMatrix(indices)
+}
+
+// rankMatrix converts a matrix of ranks 0..n-1 to thresholds (rank+0.5)/n
+func rankMatrix(ranks [][]int) [][]float64 {
+	cells := float64(len(ranks) * len(ranks[0]))
+	matrix := make([][]float64, len(ranks))
+	for y := range ranks {
+		matrix[y] = make([]float64, len(ranks[y]))
+		for x, rank := range ranks[y] {
+			matrix[y][x] = (float64(rank) + 0.5) / cells
+		}
+	}
+	return matrix
+}
@@ -0,0 +1,140 @@
+package render
+
+import (
+	"image"
+	"io"
+	"strings"
+	"math"
+)
+
+// Each cell is sampled as a grid of edgeSubdivisions x edgeSubdivisions
+// points before the Sobel pass, so edges thinner than a cell still register
+const edgeSubdivisions = 3
+
+// DefaultEdgeThreshold is the gradient magnitude (0-1 scale) above which a
+// cell is drawn as an edge
+const DefaultEdgeThreshold = 0.25
+
+// ModeEdges is the name of the Sobel line-art renderer
+const ModeEdges = "edges"
+
+func init() {
+	Register(ModeEdges, "Sobel edge detection drawn with - | / \\ (optionally over the ramp)", gridMode{imageToEdges, edgeSubdivisions, edgeSubdivisions})
+}
+
+// imageToEdges draws cells whose Sobel gradient magnitude exceeds
+// opts.EdgeThreshold with a glyph following the edge direction. Other cells
+// are blank, or use the brightness ramp when opts.EdgeOverlay is set.
+func imageToEdges(w io.Writer, img image.Image, opts Options) error {
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+	
+	// Calculate output dimensions
+	outWidth, outHeight := opts.outputSize(bounds)
+	
+	threshold := opts.EdgeThreshold
+	if threshold == 0 {
+		threshold = DefaultEdgeThreshold
+	}
+	
+	// Sample a grayscale grid at sub-cell resolution
+	gridWidth := outWidth * edgeSubdivisions
+	gridHeight := outHeight * edgeSubdivisions
+	gray := make([][]float64, gridHeight)
+	for y := range gray {
+		gray[y] = make([]float64, gridWidth)
+		for x := range gray[y] {
+			minX, minY, maxX, maxY := cellBounds(x, y, gridWidth, gridHeight, width, height)
+			r, g, b := sampleRegionColor(img, minX, minY, maxX, maxY, opts)
+			gray[y][x] = float64(applyContrast(luma(r, g, b, opts), opts.Contrast)) / 255.0
+		}
+	}
+	
+	for y := 0; y < outHeight; y++ {
+		var row strings.Builder
+		for x := 0; x < outWidth; x++ {
+			if writeBlankCell(&row, img, x, y, outWidth, outHeight, opts) {
+				continue
+			}
+			
+			// Accumulate gradients in double-angle form so that opposite
+			// gradients along the same edge reinforce rather than cancel
+			var magnitude, sumCos, sumSin, brightness float64
+			for sy := y * edgeSubdivisions; sy < (y+1)*edgeSubdivisions; sy++ {
+				for sx := x * edgeSubdivisions; sx < (x+1)*edgeSubdivisions; sx++ {
+					gx, gy := sobel(gray, sx, sy)
+					magnitude += math.Hypot(gx, gy)
+					
+					// Orientation is judged on screen, where cells are
+					// taller than wide, rather than in grid units
+					gy *= opts.cellAspect()
+					sumCos += gx*gx - gy*gy
+					sumSin += 2 * gx * gy
+					brightness += gray[sy][sx]
+				}
+			}
+			samples := float64(edgeSubdivisions * edgeSubdivisions)
+			magnitude /= samples * 4 // Sobel responses peak at 4 on a 0-1 scale
+			brightness /= samples
+			
+			var char string
+			switch {
+			case magnitude > threshold:
+				char = edgeGlyph(math.Atan2(sumSin, sumCos) / 2)
+			case opts.EdgeOverlay:
+				char = grayToASCII(int(brightness*255), opts.Invert, opts.Ramp)
+			default:
+				char = " "
+			}
+			
+			// Apply color if enabled
+			if opts.Color != ColorNone {
+				minX, minY, maxX, maxY := cellBounds(x, y, outWidth, outHeight, width, height)
+				r, g, b := sampleRegionColor(img, minX, minY, maxX, maxY, opts)
+				char = colorizeChar(char, r, g, b, opts)
+			}
+			
+			row.WriteString(char)
+		}
+		row.WriteString("\n")
+		if _, err := io.WriteString(w, row.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sobel returns the horizontal and vertical Sobel gradients at (x, y),
+// clamping neighbors to the grid edges
+func sobel(gray [][]float64, x, y int) (float64, float64) {
+	at := func(dx, dy int) float64 {
+		sx := x + dx
+		sy := y + dy
+		if sx < 0 { sx = 0 }
+		if sy < 0 { sy = 0 }
+		if sy >= len(gray) { sy = len(gray) - 1 }
+		if sx >= len(gray[sy]) { sx = len(gray[sy]) - 1 }
+		return gray[sy][sx]
+	}
+	
+	gx := at(1, -1) + 2*at(1, 0) + at(1, 1) - at(-1, -1) - 2*at(-1, 0) - at(-1, 1)
+	gy := at(-1, 1) + 2*at(0, 1) + at(1, 1) - at(-1, -1) - 2*at(0, -1) - at(1, -1)
+	return gx, gy
+}
+
+// edgeGlyph maps a gradient orientation in radians (-π/2 to π/2, y axis
+// pointing down) to the glyph drawn along the perpendicular edge
+func edgeGlyph(orientation float64) string {
+	degrees := orientation * 180 / math.Pi
+	switch {
+	case math.Abs(degrees) < 22.5:
+		return "|"
+	case math.Abs(degrees) > 67.5:
+		return "-"
+	case degrees > 0:
+		return "/"
+	default:
+		return "\\"
+	}
+}
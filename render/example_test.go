@@ -0,0 +1,90 @@
+package render_test
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/e6a5/tiv/render"
+)
+
+// gradient returns a small grayscale image with four bands stepping from
+// black on the left to white on the right
+func gradient() image.Image {
+	img := image.NewGray(image.Rect(0, 0, 8, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			img.SetGray(x, y, color.Gray{Y: uint8(x / 2 * 255 / 3)})
+		}
+	}
+	return img
+}
+
+func ExampleNew() {
+	r := render.New(render.Options{Width: 8, Height: 1})
+
+	opts := r.Options()
+	fmt.Println(opts.Mode, opts.Width, opts.Height, opts.CellAspect)
+	// Output: ascii 8 1 0.5
+}
+
+func ExampleRenderer_Render() {
+	ramp, err := render.NewRamp(".:+#")
+	if err != nil {
+		panic(err)
+	}
+
+	r := render.New(render.Options{Width: 4, Height: 2, Ramp: ramp})
+	if err := r.Render(os.Stdout, gradient()); err != nil {
+		panic(err)
+	}
+	// Output:
+	// .:+#
+	// .:+#
+}
+
+func ExampleRegister() {
+	// A mode that marks each cell as lit or dark by the pixel at its
+	// top-left corner
+	lit := render.ModeFunc(func(w io.Writer, img image.Image, opts render.Options) error {
+		bounds := img.Bounds()
+		for y := 0; y < opts.Height; y++ {
+			var row strings.Builder
+			for x := 0; x < opts.Width; x++ {
+				px := x * bounds.Dx() / opts.Width
+				py := y * bounds.Dy() / opts.Height
+				if color.GrayModel.Convert(img.At(px, py)).(color.Gray).Y >= 128 {
+					row.WriteString("#")
+				} else {
+					row.WriteString(".")
+				}
+			}
+			row.WriteString("\n")
+			if _, err := io.WriteString(w, row.String()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	// The registry is global and registrations last for the life of the
+	// program, and registering a name twice panics. Real modes register
+	// once from an init function; this example uses a name nothing else in
+	// the package uses and skips registering if it already ran.
+	const name = "example-register-lit"
+	if _, ok := render.Lookup(name); !ok {
+		render.Register(name, "lit or dark cells", lit)
+	}
+
+	// Modes always receive the final Height, here derived from the 8x4
+	// image and the default cell aspect
+	r := render.New(render.Options{Mode: name, Width: 4})
+	if err := r.Render(os.Stdout, gradient()); err != nil {
+		panic(err)
+	}
+	// Output:
+	// ..##
+}
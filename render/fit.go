@@ -0,0 +1,110 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// Fit controls how the image is sized to the Width x Height box. With a
+// zero Height the box has no height limit, so fill, contain and cover all
+// give Width cells across and as many rows as the image aspect requires.
+type Fit int
+
+const (
+	FitFill    Fit = iota // Stretch to exactly the box (the historic behaviour)
+	FitContain            // Largest size inside the box that keeps the aspect ratio
+	FitCover              // Fill the box keeping the aspect ratio, cropping the overflow evenly
+	FitNone               // Natural size of one cell per pixel across, cropped evenly to the box
+)
+
+// fitNames lists the fit names in Fit order
+var fitNames = []string{"fill", "contain", "cover", "none"}
+
+// ParseFit parses a fit name such as "contain"
+func ParseFit(name string) (Fit, error) {
+	for i, n := range fitNames {
+		if n == name {
+			return Fit(i), nil
+		}
+	}
+	return FitFill, fmt.Errorf("unknown fit %q (want one of %s)", name, strings.Join(fitNames, ", "))
+}
+
+// String returns the fit name
+func (f Fit) String() string {
+	if f < 0 || int(f) >= len(fitNames) {
+		return fmt.Sprintf("Fit(%d)", int(f))
+	}
+	return fitNames[f]
+}
+
+// modeGrid returns the samples per cell of mode, assuming one sample per
+// cell for modes that do not report their grid
+func modeGrid(mode Mode) (cols, rows int) {
+	if grid, ok := mode.(GridMode); ok {
+		return grid.Grid()
+	}
+	return 1, 1
+}
+
+// fit sizes the output to the Width x Height box of opts, cropping img
+// where the fit cuts off part of the image. The returned options hold the
+// final output size, with Height derived from the aspect ratio when it is
+// zero, so modes never need to work it out themselves.
+func fit(img image.Image, mode Mode, opts Options) (image.Image, Options) {
+	cols, _ := modeGrid(mode)
+	bounds := img.Bounds()
+
+	aspect := opts.rowsPerColumn(bounds)
+
+	switch opts.Fit {
+	case FitNone:
+		width := max(1, bounds.Dx()/cols)
+		height := max(1, int(float64(width)*aspect))
+		if width > opts.Width {
+			img = cropCenter(img, float64(opts.Width)/float64(width), 1)
+			width = opts.Width
+		}
+		if opts.Height > 0 && height > opts.Height {
+			img = cropCenter(img, 1, float64(opts.Height)/float64(height))
+			height = opts.Height
+		}
+		opts.Width, opts.Height = width, height
+
+	case FitContain:
+		if opts.Height == 0 {
+			break
+		}
+		if float64(opts.Width)*aspect <= float64(opts.Height) {
+			opts.Height = max(1, int(float64(opts.Width)*aspect))
+		} else {
+			opts.Width = max(1, int(float64(opts.Height)/aspect))
+		}
+
+	case FitCover:
+		if opts.Height == 0 {
+			break
+		}
+		boxAspect := float64(opts.Height) / float64(opts.Width)
+		if aspect > boxAspect {
+			img = cropCenter(img, 1, boxAspect/aspect)
+		} else {
+			img = cropCenter(img, aspect/boxAspect, 1)
+		}
+	}
+
+	opts.Width, opts.Height = opts.outputSize(img.Bounds())
+	return img, opts
+}
+
+// cropCenter returns a view of the middle fx by fy fraction of img
+func cropCenter(img image.Image, fx, fy float64) image.Image {
+	bounds := img.Bounds()
+	w := max(1, int(math.Round(float64(bounds.Dx())*fx)))
+	h := max(1, int(math.Round(float64(bounds.Dy())*fy)))
+	x := bounds.Min.X + (bounds.Dx()-w)/2
+	y := bounds.Min.Y + (bounds.Dy()-h)/2
+	return &croppedImage{img: img, rect: image.Rect(x, y, x+w, y+h)}
+}
@@ -0,0 +1,96 @@
+package render
+
+import (
+	"image"
+	"io"
+	"strings"
+)
+
+// Upper half block used by the half-block renderer: the foreground paints
+// the top pixel and the background paints the bottom pixel of each cell
+const upperHalfBlock = "▀"
+
+// Lower half block, used when only the bottom pixel of a cell is drawn
+const lowerHalfBlock = "▄"
+
+// ModeHalfBlock is the name of the two-pixels-per-cell renderer
+const ModeHalfBlock = "halfblock"
+
+func init() {
+	Register(ModeHalfBlock, "'▀' cells with top/bottom pixel colors for 2x vertical resolution", gridMode{imageToHalfBlocks, 1, 2})
+}
+
+// imageToHalfBlocks renders each cell as two vertically stacked pixels.
+// In color modes the top pixel becomes the foreground and the bottom pixel
+// the background; without color each half is thresholded to on or off.
+func imageToHalfBlocks(w io.Writer, img image.Image, opts Options) error {
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+	
+	// Calculate output dimensions
+	outWidth, outHeight := opts.outputSize(bounds)
+	
+	// Each cell covers two rows of pixels
+	pixelRows := outHeight * 2
+	
+	for y := 0; y < outHeight; y++ {
+		var row strings.Builder
+		for x := 0; x < outWidth; x++ {
+			if writeBlankCell(&row, img, x, y, outWidth, outHeight, opts) {
+				continue
+			}
+			
+			minX, minY, maxX, maxY := cellBounds(x, 2*y, outWidth, pixelRows, width, height)
+			tr, tg, tb := sampleRegionColor(img, minX, minY, maxX, maxY, opts)
+			topBlank := blankCell(img, x, 2*y, outWidth, pixelRows, opts)
+			minX, minY, maxX, maxY = cellBounds(x, 2*y+1, outWidth, pixelRows, width, height)
+			br, bg, bb := sampleRegionColor(img, minX, minY, maxX, maxY, opts)
+			bottomBlank := blankCell(img, x, 2*y+1, outWidth, pixelRows, opts)
+			
+			if opts.Color != ColorNone {
+				switch {
+				case topBlank:
+					row.WriteString(fgColorCode(br, bg, bb, opts) + lowerHalfBlock + "\033[0m")
+				case bottomBlank:
+					row.WriteString(fgColorCode(tr, tg, tb, opts) + upperHalfBlock + "\033[0m")
+				default:
+					row.WriteString(colorizeCell(upperHalfBlock, [3]uint8{tr, tg, tb}, [3]uint8{br, bg, bb}, opts))
+				}
+				continue
+			}
+			
+			top := applyContrast(luma(tr, tg, tb, opts), opts.Contrast)
+			bottom := applyContrast(luma(br, bg, bb, opts), opts.Contrast)
+			row.WriteString(grayToHalfBlock(top, bottom, opts.Invert, topBlank, bottomBlank))
+		}
+		row.WriteString("\n")
+		if _, err := io.WriteString(w, row.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// grayToHalfBlock picks the half block character whose lit halves match
+// the thresholded top and bottom gray values. Blank halves are never lit.
+func grayToHalfBlock(top, bottom int, invert, topBlank, bottomBlank bool) string {
+	if invert {
+		top = 255 - top
+		bottom = 255 - bottom
+	}
+	
+	topOn := top >= 128 && !topBlank
+	bottomOn := bottom >= 128 && !bottomBlank
+	
+	switch {
+	case topOn && bottomOn:
+		return "█"
+	case topOn:
+		return upperHalfBlock
+	case bottomOn:
+		return lowerHalfBlock
+	default:
+		return " "
+	}
+}
@@ -0,0 +1,128 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Mode is the renderer interface implemented by every output style.
+// Render is called once per image, or once per chunk for very large images,
+// and must write opts.Height newline-terminated rows of exactly opts.Width
+// cells to w. The Renderer resolves both before calling Render, so neither
+// is ever zero there, even when the caller left Height to the aspect ratio.
+// Modes should build each row and write it with one checked write, since w
+// may be any writer when a mode is obtained from Lookup.
+//
+// With opts.BlankTransparent set, a cell whose pixels are all fully
+// transparent must be written as a plain space, leaving it to the terminal
+// background.
+type Mode interface {
+	Render(w io.Writer, img image.Image, opts Options) error
+}
+
+// ModeFunc adapts an ordinary function to the Mode interface
+type ModeFunc func(w io.Writer, img image.Image, opts Options) error
+
+// Render calls f(w, img, opts)
+func (f ModeFunc) Render(w io.Writer, img image.Image, opts Options) error {
+	return f(w, img, opts)
+}
+
+// ModeInfo describes a registered mode
+type ModeInfo struct {
+	Name        string
+	Description string
+}
+
+type registeredMode struct {
+	info ModeInfo
+	mode Mode
+}
+
+var (
+	modesMu sync.RWMutex
+	modes   = make(map[string]registeredMode)
+)
+
+// Built-in mode names
+const (
+	ModeASCII  = "ascii"
+	ModeBlocks = "blocks"
+	ModeDither = "dither"
+)
+
+func init() {
+	Register(ModeASCII, "brightness-mapped ASCII character ramp", gridMode{imageToASCII, 1, 1})
+	Register(ModeBlocks, "Unicode partial block ramp", gridMode{imageToBlocks, 1, 1})
+	Register(ModeDither, "Floyd-Steinberg dithered ASCII (or blocks with UseBlocks)", gridMode{imageToArtWithDithering, 1, 1})
+}
+
+// Register makes a mode available under name. It is intended to be called
+// from an init function and panics if name is empty, mode is nil or name is
+// already registered.
+func Register(name, description string, mode Mode) {
+	if name == "" {
+		panic("render: Register with empty mode name")
+	}
+	if mode == nil {
+		panic("render: Register mode is nil")
+	}
+
+	modesMu.Lock()
+	defer modesMu.Unlock()
+
+	if _, dup := modes[name]; dup {
+		panic(fmt.Sprintf("render: Register called twice for mode %q", name))
+	}
+	modes[name] = registeredMode{
+		info: ModeInfo{Name: name, Description: description},
+		mode: mode,
+	}
+}
+
+// Lookup returns the mode registered under name
+func Lookup(name string) (Mode, bool) {
+	modesMu.RLock()
+	defer modesMu.RUnlock()
+
+	m, ok := modes[name]
+	return m.mode, ok
+}
+
+// Modes returns all registered modes sorted by name
+func Modes() []ModeInfo {
+	modesMu.RLock()
+	defer modesMu.RUnlock()
+
+	infos := make([]ModeInfo, 0, len(modes))
+	for _, m := range modes {
+		infos = append(infos, m.info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// ModeNames returns the names of all registered modes sorted alphabetically
+func ModeNames() []string {
+	infos := Modes()
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name
+	}
+	return names
+}
+
+// legacyMode maps the UseBlocks and Dither switches to a mode name
+func legacyMode(opts Options) string {
+	if opts.Dither {
+		return ModeDither
+	} else if opts.UseBlocks {
+		return ModeBlocks
+	}
+	return ModeASCII
+}
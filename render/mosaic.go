@@ -0,0 +1,176 @@
+package render
+
+import (
+	"image"
+	"io"
+	"strings"
+)
+
+// Quadrant characters indexed by a 4-bit pattern where bit 0 is the top-left,
+// bit 1 the top-right, bit 2 the bottom-left and bit 3 the bottom-right quarter
+var quadrantChars = []rune(" ▘▝▀▖▌▞▛▗▚▐▜▄▙▟█")
+
+// Sextants live in Symbols for Legacy Computing starting at U+1FB00, which
+// omits the four patterns already covered by space, '▌', '▐' and '█'
+const sextantBase = 0x1FB00
+
+// Mosaic mode names
+const (
+	ModeQuadrant = "quadrant"
+	ModeSextant  = "sextant"
+)
+
+func init() {
+	Register(ModeQuadrant, "2x2 quadrant blocks with best-fit foreground/background colors", gridMode{imageToQuadrants, 2, 2})
+	Register(ModeSextant, "2x3 sextant blocks with best-fit foreground/background colors", gridMode{imageToSextants, 2, 3})
+}
+
+// imageToQuadrants renders each cell as a 2x2 quadrant mosaic
+func imageToQuadrants(w io.Writer, img image.Image, opts Options) error {
+	return imageToMosaic(w, img, opts, 2, quadrantChar)
+}
+
+// imageToSextants renders each cell as a 2x3 sextant mosaic
+func imageToSextants(w io.Writer, img image.Image, opts Options) error {
+	return imageToMosaic(w, img, opts, 3, sextantChar)
+}
+
+// quadrantChar returns the quadrant character for a 4-bit pattern
+func quadrantChar(pattern int) string {
+	return string(quadrantChars[pattern])
+}
+
+// sextantChar returns the sextant character for a 6-bit pattern where bits
+// run left to right, top to bottom
+func sextantChar(pattern int) string {
+	switch pattern {
+	case 0:
+		return " "
+	case 21:
+		return "▌"
+	case 42:
+		return "▐"
+	case 63:
+		return "█"
+	}
+	
+	index := pattern - 1
+	if pattern > 21 {
+		index--
+	}
+	if pattern > 42 {
+		index--
+	}
+	return string(rune(sextantBase + index))
+}
+
+// imageToMosaic renders cells split into a 2 x rows grid of sub-pixels. In
+// color modes each cell uses the two-color partition of its sub-pixels with
+// the least squared error; without color each sub-pixel is thresholded.
+func imageToMosaic(w io.Writer, img image.Image, opts Options, rows int, glyph func(pattern int) string) error {
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+	
+	// Calculate output dimensions
+	outWidth, outHeight := opts.outputSize(bounds)
+	
+	subWidth := outWidth * 2
+	subHeight := outHeight * rows
+	pixels := make([][3]uint8, 2*rows)
+	
+	for y := 0; y < outHeight; y++ {
+		var row strings.Builder
+		for x := 0; x < outWidth; x++ {
+			if writeBlankCell(&row, img, x, y, outWidth, outHeight, opts) {
+				continue
+			}
+			
+			// Sample sub-pixels in pattern bit order
+			for i := range pixels {
+				sx, sy := x*2+i%2, y*rows+i/2
+				minX, minY, maxX, maxY := cellBounds(sx, sy, subWidth, subHeight, width, height)
+				r, g, b := sampleRegionColor(img, minX, minY, maxX, maxY, opts)
+				pixels[i] = [3]uint8{r, g, b}
+			}
+			
+			if opts.Color != ColorNone {
+				pattern, fg, bg := fitTwoColors(pixels)
+				row.WriteString(colorizeCell(glyph(pattern), fg, bg, opts))
+				continue
+			}
+			
+			pattern := 0
+			for i, p := range pixels {
+				gray := applyContrast(luma(p[0], p[1], p[2], opts), opts.Contrast)
+				if opts.Invert {
+					gray = 255 - gray
+				}
+				if gray >= 128 {
+					pattern |= 1 << i
+				}
+			}
+			row.WriteString(glyph(pattern))
+		}
+		row.WriteString("\n")
+		if _, err := io.WriteString(w, row.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fitTwoColors tries every partition of pixels into a foreground and a
+// background set and returns the one whose set means give the least squared
+// error. Bit i of pattern is set when pixels[i] belongs to the foreground.
+func fitTwoColors(pixels [][3]uint8) (pattern int, fg, bg [3]uint8) {
+	full := 1<<len(pixels) - 1
+	bestErr := -1
+	
+	// Complementary patterns are equivalent, so keep pixel 0 in the foreground.
+	// Starting from the full pattern makes uniform cells render as solid blocks.
+	for p := full; p > 0; p -= 2 {
+		var sums [2][3]int
+		var counts [2]int
+		for i, px := range pixels {
+			set := (p >> i) & 1
+			for c := 0; c < 3; c++ {
+				sums[set][c] += int(px[c])
+			}
+			counts[set]++
+		}
+		
+		var means [2][3]int
+		for set := 0; set < 2; set++ {
+			if counts[set] == 0 {
+				continue
+			}
+			for c := 0; c < 3; c++ {
+				means[set][c] = sums[set][c] / counts[set]
+			}
+		}
+		
+		err := 0
+		for i, px := range pixels {
+			set := (p >> i) & 1
+			for c := 0; c < 3; c++ {
+				d := int(px[c]) - means[set][c]
+				err += d * d
+			}
+		}
+		
+		if bestErr < 0 || err < bestErr {
+			bestErr = err
+			pattern = p
+			for c := 0; c < 3; c++ {
+				fg[c] = uint8(means[1][c])
+				bg[c] = uint8(means[0][c])
+			}
+			if counts[0] == 0 {
+				bg = fg
+			}
+		}
+	}
+	
+	return pattern, fg, bg
+}
@@ -0,0 +1,62 @@
+package render
+
+import "testing"
+
+func TestSextantChar(t *testing.T) {
+	tests := []struct {
+		pattern int
+		want    string
+	}{
+		{0, " "},
+		{1, "\U0001FB00"},
+		{20, "\U0001FB13"},
+		{21, "▌"},
+		{22, "\U0001FB14"},
+		{42, "▐"},
+		{62, "\U0001FB3B"},
+		{63, "█"},
+	}
+
+	for _, tt := range tests {
+		if got := sextantChar(tt.pattern); got != tt.want {
+			t.Errorf("sextantChar(%d) = %U, want %U", tt.pattern, []rune(got), []rune(tt.want))
+		}
+	}
+}
+
+func TestFitTwoColorsUniform(t *testing.T) {
+	tests := []struct {
+		name   string
+		pixels int
+		glyph  func(int) string
+	}{
+		{"quadrant", 4, quadrantChar},
+		{"sextant", 6, sextantChar},
+	}
+
+	for _, tt := range tests {
+		pixels := make([][3]uint8, tt.pixels)
+		for i := range pixels {
+			pixels[i] = [3]uint8{200, 100, 50}
+		}
+
+		pattern, fg, bg := fitTwoColors(pixels)
+		if glyph := tt.glyph(pattern); glyph != "█" {
+			t.Errorf("uniform %s cell drew %q, want a full block", tt.name, glyph)
+		}
+		if fg != pixels[0] || bg != fg {
+			t.Errorf("uniform %s cell got fg %v, bg %v, want both %v", tt.name, fg, bg, pixels[0])
+		}
+	}
+}
+
+func TestFitTwoColorsSplit(t *testing.T) {
+	// Left column white, right column black: the left half block
+	black, white := [3]uint8{0, 0, 0}, [3]uint8{255, 255, 255}
+	pixels := [][3]uint8{white, black, white, black, white, black}
+
+	pattern, fg, bg := fitTwoColors(pixels)
+	if glyph := sextantChar(pattern); glyph != "▌" || fg != white || bg != black {
+		t.Errorf("fitTwoColors = %q fg %v bg %v, want ▌ fg %v bg %v", glyph, fg, bg, white, black)
+	}
+}
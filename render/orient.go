@@ -0,0 +1,90 @@
+package render
+
+import (
+	"image"
+	"image/color"
+)
+
+// Orientation is an EXIF orientation: how the stored pixels must be rotated
+// and flipped to display the image upright
+type Orientation int
+
+// The eight EXIF orientation values
+const (
+	OrientationNormal     Orientation = iota + 1
+	OrientationFlipH                  // Mirrored left to right
+	OrientationRotate180              // Upside down
+	OrientationFlipV                  // Mirrored top to bottom
+	OrientationTranspose              // Mirrored along the top-left to bottom-right diagonal
+	OrientationRotate90               // Needs a 90° clockwise turn
+	OrientationTransverse             // Mirrored along the top-right to bottom-left diagonal
+	OrientationRotate270              // Needs a 90° counter-clockwise turn
+)
+
+// Orient returns an upright view of img stored with orientation o. The view
+// shares img's pixels; img is returned as is for OrientationNormal and for
+// values outside 1-8.
+func Orient(img image.Image, o Orientation) image.Image {
+	if o <= OrientationNormal || o > OrientationRotate270 {
+		return img
+	}
+	return &orientedImage{img: img, orientation: o}
+}
+
+// orientedImage is a rotated and/or flipped view of an image
+type orientedImage struct {
+	img         image.Image
+	orientation Orientation
+}
+
+// swapsAxes reports whether the orientation turns the image on its side
+func (o *orientedImage) swapsAxes() bool {
+	return o.orientation >= OrientationTranspose
+}
+
+// ColorModel implements image.Image interface
+func (o *orientedImage) ColorModel() color.Model {
+	return o.img.ColorModel()
+}
+
+// Bounds implements image.Image interface
+func (o *orientedImage) Bounds() image.Rectangle {
+	size := o.img.Bounds().Size()
+	if o.swapsAxes() {
+		return image.Rect(0, 0, size.Y, size.X)
+	}
+	return image.Rect(0, 0, size.X, size.Y)
+}
+
+// At implements image.Image interface
+func (o *orientedImage) At(x, y int) color.Color {
+	if !image.Pt(x, y).In(o.Bounds()) {
+		return o.img.ColorModel().Convert(color.Transparent)
+	}
+
+	src := o.img.Bounds()
+	w, h := src.Dx(), src.Dy()
+
+	// Map the upright coordinates back to the stored pixel
+	var sx, sy int
+	switch o.orientation {
+	case OrientationFlipH:
+		sx, sy = w-1-x, y
+	case OrientationRotate180:
+		sx, sy = w-1-x, h-1-y
+	case OrientationFlipV:
+		sx, sy = x, h-1-y
+	case OrientationTranspose:
+		sx, sy = y, x
+	case OrientationRotate90:
+		sx, sy = y, h-1-x
+	case OrientationTransverse:
+		sx, sy = w-1-y, h-1-x
+	case OrientationRotate270:
+		sx, sy = w-1-y, x
+	default:
+		sx, sy = x, y
+	}
+
+	return o.img.At(src.Min.X+sx, src.Min.Y+sy)
+}
@@ -0,0 +1,200 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ColorMetric selects how sampled colors are matched to palette entries
+type ColorMetric int
+
+const (
+	ColorMetricOKLab  ColorMetric = iota // nearest entry by OKLab distance over the whole palette
+	ColorMetricLegacy                    // 6x6x6 cube bucketing (256) and redmean distance (16/8)
+)
+
+// paletteLUT finds the nearest palette entry by OKLab distance with an
+// exact search over the whole palette, remembering the answer for each RGB
+// value it has seen so repeated colors stay a single map read
+type paletteLUT struct {
+	once    sync.Once
+	colors  [][3]uint8   // palette entries to search
+	offset  int          // palette index of colors[0]
+	entries [][3]float64 // colors in OKLab, filled on first use
+	nearest sync.Map     // packed RGB -> index into colors
+}
+
+var (
+	// xterm palette including the 16 theme-dependent system colors
+	xterm256LUT = &paletteLUT{colors: xterm256Palette[:]}
+	// xterm palette restricted to the fixed cube and gray ramp (16-255)
+	xtermFixedLUT = &paletteLUT{colors: xterm256Palette[16:], offset: 16}
+	ansi16LUT     = &paletteLUT{colors: ansi16Palette[:]}
+	ansi8LUT      = &paletteLUT{colors: ansi16Palette[:8]}
+)
+
+// Palette is a custom set of output colors that every sampled color is
+// snapped to before it is written in the active color mode. The zero
+// Palette leaves colors to the color mode's own palette.
+type Palette struct {
+	lut *paletteLUT
+}
+
+// NewPalette creates a palette from 1 to 256 colors
+func NewPalette(colors []color.Color) (Palette, error) {
+	if len(colors) < 1 || len(colors) > 256 {
+		return Palette{}, fmt.Errorf("palette needs 1 to 256 colors, got %d", len(colors))
+	}
+
+	entries := make([][3]uint8, len(colors))
+	for i, c := range colors {
+		r, g, b, _ := c.RGBA()
+		entries[i] = [3]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)}
+	}
+	return Palette{lut: &paletteLUT{colors: entries}}, nil
+}
+
+// ParsePalette creates a palette from a comma-separated list of hex colors
+// such as "#000000,#ff8800,fff"
+func ParsePalette(spec string) (Palette, error) {
+	var colors []color.Color
+	for _, field := range strings.Split(spec, ",") {
+		c, ok := parseHexColor(strings.TrimSpace(field))
+		if !ok {
+			return Palette{}, fmt.Errorf("invalid palette color %q", field)
+		}
+		colors = append(colors, c)
+	}
+	return NewPalette(colors)
+}
+
+// parseHexColor parses a "#rgb" or "#rrggbb" color; the '#' is optional
+func parseHexColor(s string) (color.RGBA, bool) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, true
+}
+
+// Len returns the number of colors in the palette
+func (p Palette) Len() int {
+	if p.lut == nil {
+		return 0
+	}
+	return len(p.lut.colors)
+}
+
+// snap returns the palette color nearest to r, g, b, or r, g, b unchanged
+// for the zero Palette
+func (p Palette) snap(r, g, b uint8) (uint8, uint8, uint8) {
+	if p.lut == nil {
+		return r, g, b
+	}
+	c := p.lut.colors[p.lut.lookup(r, g, b)]
+	return c[0], c[1], c[2]
+}
+
+// paletteSpread estimates the distance between neighboring colors of the
+// active palette on each RGB axis, used to scale ordered dithering
+func paletteSpread(opts Options) float64 {
+	size := opts.Palette.Len()
+	if size == 0 {
+		switch opts.Color {
+		case Color8:
+			size = 8
+		case Color16:
+			size = 16
+		case Color256:
+			size = 216 // The 6x6x6 cube sets the spacing
+		default:
+			return 0
+		}
+	}
+
+	steps := math.Cbrt(float64(size)) - 1
+	if steps < 1 {
+		steps = 1
+	}
+	return 255.0 / steps
+}
+
+// xterm256Palette holds the RGB values of the xterm 256-color palette:
+// the 16 system colors, the 6x6x6 color cube and the 24-step gray ramp
+var xterm256Palette = func() [256][3]uint8 {
+	var p [256][3]uint8
+	copy(p[:16], ansi16Palette[:])
+
+	cubeLevels := [6]uint8{0, 95, 135, 175, 215, 255}
+	for i := 0; i < 216; i++ {
+		p[16+i] = [3]uint8{cubeLevels[i/36], cubeLevels[(i/6)%6], cubeLevels[i%6]}
+	}
+
+	for i := 0; i < 24; i++ {
+		gray := uint8(8 + 10*i)
+		p[232+i] = [3]uint8{gray, gray, gray}
+	}
+	return p
+}()
+
+// lookup returns the palette index of the entry nearest to r, g, b
+func (l *paletteLUT) lookup(r, g, b uint8) int {
+	l.once.Do(l.build)
+
+	key := uint32(r)<<16 | uint32(g)<<8 | uint32(b)
+	if i, ok := l.nearest.Load(key); ok {
+		return l.offset + i.(int)
+	}
+
+	lab := rgbToOKLab(r, g, b)
+	best := 0
+	bestDist := math.Inf(1)
+	for i, e := range l.entries {
+		dl, da, db := lab[0]-e[0], lab[1]-e[1], lab[2]-e[2]
+		if dist := dl*dl + da*da + db*db; dist < bestDist {
+			bestDist = dist
+			best = i
+		}
+	}
+
+	l.nearest.Store(key, best)
+	return l.offset + best
+}
+
+// build converts the palette entries to OKLab
+func (l *paletteLUT) build() {
+	l.entries = make([][3]float64, len(l.colors))
+	for i, c := range l.colors {
+		l.entries[i] = rgbToOKLab(c[0], c[1], c[2])
+	}
+}
+
+// rgbToOKLab converts an sRGB color to OKLab (L, a, b)
+func rgbToOKLab(r, g, b uint8) [3]float64 {
+	lr := srgbToLinear(r)
+	lg := srgbToLinear(g)
+	lb := srgbToLinear(b)
+
+	l := math.Cbrt(0.4122214708*lr + 0.5363325363*lg + 0.0514459929*lb)
+	m := math.Cbrt(0.2119034982*lr + 0.6806995451*lg + 0.1073969566*lb)
+	s := math.Cbrt(0.0883024619*lr + 0.2817188376*lg + 0.6299787005*lb)
+
+	return [3]float64{
+		0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+// srgbToLinear decodes an 8-bit sRGB channel to linear light (0-1)
+func srgbToLinear(c uint8) float64 {
+	return decodeSRGB(float64(c) / 255.0)
+}
@@ -0,0 +1,31 @@
+package render
+
+import "testing"
+
+func TestPaletteLookupExactEntries(t *testing.T) {
+	// Without system colors every cube and gray ramp entry is distinct
+	for i := 16; i < 256; i++ {
+		c := xterm256Palette[i]
+		if got := xtermFixedLUT.lookup(c[0], c[1], c[2]); got != i {
+			t.Errorf("fixed 256-color lookup of entry %d %v = %d %v", i, c, got, xterm256Palette[got])
+		}
+	}
+
+	// System colors may repeat cube entries, so only the color must match
+	for i, c := range xterm256Palette {
+		if got := xterm256LUT.lookup(c[0], c[1], c[2]); xterm256Palette[got] != c {
+			t.Errorf("256-color lookup of entry %d %v = %d %v", i, c, got, xterm256Palette[got])
+		}
+	}
+
+	for i, c := range ansi16Palette {
+		if got := ansi16LUT.lookup(c[0], c[1], c[2]); got != i {
+			t.Errorf("16-color lookup of entry %d %v = %d", i, c, got)
+		}
+	}
+	for i, c := range ansi16Palette[:8] {
+		if got := ansi8LUT.lookup(c[0], c[1], c[2]); got != i {
+			t.Errorf("8-color lookup of entry %d %v = %d", i, c, got)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"sort"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// Ramp is a sequence of characters ordered from least to most ink, each
+// paired with the 0-255 brightness level it represents. The zero Ramp is
+// the standard preset.
+type Ramp struct {
+	chars  []string
+	levels []float64
+}
+
+// Named ramp presets, each ordered from least to most ink
+var rampPresets = map[string]string{
+	"standard": asciiChars,
+	"detailed": " .'`^\",:;Il!i><~+_-?][}{1)(|\\/tfjrxnuvczXYUJCLQ0OZmwqpdbkhao*#MW&8%B@$",
+	"simple":   " .-+*#@",
+	"digits":   " 7104236958",
+	"katakana": " ･ｰﾍﾉｼﾘｿﾂﾝｸﾀﾅﾒﾎﾏﾓｦﾖﾗﾜ", // half-width forms, one cell each
+	"blocks":   halfBlocks,
+}
+
+// defaultRamp is used whenever Options.Ramp is the zero Ramp
+var defaultRamp = mustRamp(asciiChars)
+
+// defaultBlockRamp replaces the zero Ramp in the block modes
+var defaultBlockRamp = mustRamp(halfBlocks)
+
+// NewRamp creates a ramp from chars with evenly spaced brightness levels
+func NewRamp(chars string) (Ramp, error) {
+	runes := []rune(chars)
+	if len(runes) < 2 {
+		return Ramp{}, fmt.Errorf("character ramp needs at least 2 characters, got %d", len(runes))
+	}
+
+	r := Ramp{
+		chars:  make([]string, len(runes)),
+		levels: make([]float64, len(runes)),
+	}
+	for i, c := range runes {
+		r.chars[i] = string(c)
+		r.levels[i] = float64(i) * 255.0 / float64(len(runes)-1)
+	}
+	return r, nil
+}
+
+// mustRamp is like NewRamp but panics on error; used for built-in ramps
+func mustRamp(chars string) Ramp {
+	r, err := NewRamp(chars)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// PresetRamp returns the named preset ramp
+func PresetRamp(name string) (Ramp, bool) {
+	chars, ok := rampPresets[name]
+	if !ok {
+		return Ramp{}, false
+	}
+	return mustRamp(chars), true
+}
+
+// RampPresets returns the names of all preset ramps sorted alphabetically
+func RampPresets() []string {
+	names := make([]string, 0, len(rampPresets))
+	for name := range rampPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseRamp returns the preset named spec, or a ramp made of the literal
+// characters in spec when no preset has that name
+func ParseRamp(spec string) (Ramp, error) {
+	if r, ok := PresetRamp(spec); ok {
+		return r, nil
+	}
+	return NewRamp(spec)
+}
+
+// CalibrateRamp measures the ink coverage of each character of r in face,
+// then re-sorts the characters by coverage and spaces their brightness
+// levels in proportion to it
+func CalibrateRamp(r Ramp, face font.Face) (Ramp, error) {
+	r = r.orDefault()
+
+	// Every glyph is measured in a cell as wide as the widest one, just as
+	// a terminal places each character in a fixed-width cell
+	metrics := face.Metrics()
+	cellHeight := (metrics.Ascent + metrics.Descent).Ceil()
+	cellWidth := 0
+	for _, char := range r.chars {
+		if advance := font.MeasureString(face, char).Ceil(); advance > cellWidth {
+			cellWidth = advance
+		}
+	}
+	if cellWidth <= 0 || cellHeight <= 0 {
+		return Ramp{}, fmt.Errorf("font has no glyphs for characters %q", r.String())
+	}
+
+	type measured struct {
+		char     string
+		coverage float64
+	}
+	glyphs := make([]measured, 0, len(r.chars))
+
+	for _, char := range r.chars {
+		cell := image.NewAlpha(image.Rect(0, 0, cellWidth, cellHeight))
+		drawer := font.Drawer{
+			Dst:  cell,
+			Src:  image.Opaque,
+			Face: face,
+			Dot:  fixed.Point26_6{Y: metrics.Ascent},
+		}
+		drawer.DrawString(char)
+
+		ink := 0
+		for _, a := range cell.Pix {
+			ink += int(a)
+		}
+		glyphs = append(glyphs, measured{
+			char:     char,
+			coverage: float64(ink) / float64(255*len(cell.Pix)),
+		})
+	}
+
+	sort.SliceStable(glyphs, func(i, j int) bool {
+		return glyphs[i].coverage < glyphs[j].coverage
+	})
+
+	minCoverage := glyphs[0].coverage
+	maxCoverage := glyphs[len(glyphs)-1].coverage
+	if maxCoverage <= minCoverage {
+		return Ramp{}, fmt.Errorf("characters %q all have the same ink coverage in this font", r.String())
+	}
+
+	calibrated := Ramp{
+		chars:  make([]string, len(glyphs)),
+		levels: make([]float64, len(glyphs)),
+	}
+	for i, g := range glyphs {
+		calibrated.chars[i] = g.char
+		calibrated.levels[i] = (g.coverage - minCoverage) / (maxCoverage - minCoverage) * 255.0
+	}
+	return calibrated, nil
+}
+
+// String returns the ramp characters from least to most ink
+func (r Ramp) String() string {
+	var s string
+	for _, c := range r.orDefault().chars {
+		s += c
+	}
+	return s
+}
+
+// orDefault returns the standard ramp in place of the zero Ramp
+func (r Ramp) orDefault() Ramp {
+	if len(r.chars) == 0 {
+		return defaultRamp
+	}
+	return r
+}
+
+// orBlocks returns the Unicode block ramp in place of the zero Ramp
+func (r Ramp) orBlocks() Ramp {
+	if len(r.chars) == 0 {
+		return defaultBlockRamp
+	}
+	return r
+}
+
+// lookup returns the densest character whose level does not exceed gray,
+// along with that level. With evenly spaced levels this is the classic
+// gray * (len-1) / 255 index.
+func (r Ramp) lookup(gray float64) (string, float64) {
+	r = r.orDefault()
+
+	// The epsilon absorbs rounding in the evenly spaced levels
+	index := sort.Search(len(r.levels), func(i int) bool {
+		return r.levels[i] > gray+1e-9
+	}) - 1
+	if index < 0 {
+		index = 0
+	}
+
+	return r.chars[index], r.levels[index]
+}
@@ -0,0 +1,175 @@
+// Package render converts images to terminal text art.
+//
+// It is the engine behind the tiv command and can be used directly from Go
+// code without shelling out to the binary:
+//
+//	img, _, err := image.Decode(file)
+//	if err != nil {
+//		return err
+//	}
+//	r := render.New(render.Options{Width: 60, Color: render.Color24bit})
+//	if err := r.Render(os.Stdout, img); err != nil {
+//		return err
+//	}
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+)
+
+// ASCII characters ordered by brightness (darkest to lightest)
+// Using more characters for better density representation
+const asciiChars = " .':;!>*+%S#@"
+
+// Unicode half-block characters for even higher resolution
+const halfBlocks = " ▁▂▃▄▅▆▇█"
+
+// ColorMode represents different color output modes
+type ColorMode int
+
+const (
+	ColorNone  ColorMode = iota
+	Color256             // 256-color mode
+	Color24bit           // 24-bit truecolor mode
+	Color16              // 16 standard and bright ANSI colors
+	Color8               // 8 standard ANSI colors
+)
+
+// Default option values applied by New for zero fields
+const (
+	DefaultWidth      = 80
+	DefaultContrast   = 1.0
+	DefaultCellAspect = 0.5 // Typical terminal fonts are twice as tall as wide
+)
+
+// Options controls how an image is rendered
+type Options struct {
+	Mode       string    // Registered mode name (derived from UseBlocks/Dither if empty)
+	Width      int       // Output width in characters (DefaultWidth if 0)
+	Height     int       // Output height in characters (auto-calculated if 0)
+	Fit        Fit       // How the image is sized to the Width x Height box (FitFill if zero)
+	CellAspect float64   // Width divided by height of a character cell (DefaultCellAspect if 0)
+	Invert     bool      // Invert brightness levels
+	Contrast   float64   // Contrast adjustment (DefaultContrast if 0)
+	Ramp       Ramp      // Character ramp (standard preset if zero, or the blocks preset with blocks output)
+	UseBlocks  bool      // Use Unicode block characters instead of ASCII (legacy switch, see Mode)
+	Dither     bool      // Apply dithering (legacy switch, see Mode)
+	Color      ColorMode // ANSI color output mode
+
+	Background       Background // What transparent pixels are composited onto (BackgroundTerminal if zero)
+	BlankTransparent bool       // Draw fully transparent cells as blank, default-background cells
+
+	LinearLight bool      // Average pixels in linear light instead of raw sRGB values
+	Luma        LumaModel // Coefficients used to reduce colors to brightness (LumaRec601 if zero)
+
+	DitherAlgorithm string // Dithering algorithm name (DefaultDitherAlgorithm if empty)
+	Serpentine      bool   // Scan alternate rows right to left with error-diffusion dithering
+
+	ColorMetric  ColorMetric // How colors are matched to the 8/16/256 color palettes
+	SystemColors bool        // Let 256-color output use the 16 theme-dependent system colors
+	Palette      Palette     // Custom colors to snap output to (terminal palette if zero)
+
+	ColorBackground bool   // Color the cell background and draw CellGlyph instead of a colored character (not in halfblock, quadrant or sextant)
+	CellGlyph       string // Character drawn in every cell with ColorBackground (space if empty)
+
+	Resample string // Kernel resizing the image to the mode's sampling grid first (box-sample the original if empty)
+
+	EdgeThreshold float64 // Gradient magnitude for edge glyphs in edges mode (DefaultEdgeThreshold if 0)
+	EdgeOverlay   bool    // Draw non-edge cells with the ramp in edges mode instead of blanks
+}
+
+// cellAspect returns CellAspect, or DefaultCellAspect when it is zero
+func (o Options) cellAspect() float64 {
+	if o.CellAspect == 0 {
+		return DefaultCellAspect
+	}
+	return o.CellAspect
+}
+
+// rowsPerColumn returns the output rows per column that keep an image with
+// bounds b undistorted
+func (o Options) rowsPerColumn(b image.Rectangle) float64 {
+	return float64(b.Dy()) / float64(b.Dx()) * o.cellAspect()
+}
+
+// outputSize returns the output size in cells for an image with bounds b:
+// Width by Height, deriving Height from the aspect ratio when it is zero.
+// A derived Height is at least one row, however wide the image.
+func (o Options) outputSize(b image.Rectangle) (width, height int) {
+	if o.Height != 0 {
+		return o.Width, o.Height
+	}
+	return o.Width, max(1, int(float64(o.Width)*o.rowsPerColumn(b)))
+}
+
+// Renderer converts images to text art using a fixed set of options.
+// A Renderer is safe for concurrent use.
+type Renderer struct {
+	opts Options
+}
+
+// New creates a Renderer from opts, filling zero Width and Contrast with
+// defaults and deriving Mode from UseBlocks and Dither when it is empty
+func New(opts Options) *Renderer {
+	if opts.Mode == "" {
+		opts.Mode = legacyMode(opts)
+	}
+	if opts.Width == 0 {
+		opts.Width = DefaultWidth
+	}
+	if opts.Contrast == 0 {
+		opts.Contrast = DefaultContrast
+	}
+	if opts.CellAspect == 0 {
+		opts.CellAspect = DefaultCellAspect
+	}
+	if opts.EdgeThreshold == 0 {
+		opts.EdgeThreshold = DefaultEdgeThreshold
+	}
+	return &Renderer{opts: opts}
+}
+
+// Options returns the options the Renderer was created with, after defaults
+func (r *Renderer) Options() Options {
+	return r.opts
+}
+
+// Render converts img to text art and writes it to w
+func (r *Renderer) Render(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	if bounds.Dx() < 1 || bounds.Dy() < 1 {
+		return fmt.Errorf("image has dimensions %dx%d", bounds.Dx(), bounds.Dy())
+	}
+	if r.opts.Width < 1 || r.opts.Height < 0 {
+		return fmt.Errorf("invalid output size %dx%d", r.opts.Width, r.opts.Height)
+	}
+
+	// Renderers address pixels from the origin
+	if bounds.Min != (image.Point{}) {
+		img = &croppedImage{img: img, rect: bounds}
+	}
+
+	mode, ok := Lookup(r.opts.Mode)
+	if !ok {
+		return fmt.Errorf("unknown render mode %q", r.opts.Mode)
+	}
+	if _, err := newDitherer(r.opts); err != nil {
+		return err
+	}
+
+	img, opts := fit(img, mode, r.opts)
+	img, opts, err := resample(img, mode, opts)
+	if err != nil {
+		return err
+	}
+	img = composite(img, opts)
+
+	bw := bufio.NewWriter(w)
+	if err := newChunkedProcessor(mode, opts).processLargeImageOptimized(bw, img); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,42 @@
+package render
+
+import (
+	"errors"
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestOutputSizeAtLeastOneRow(t *testing.T) {
+	opts := New(Options{Width: 1}).Options()
+	if w, h := opts.outputSize(image.Rect(0, 0, 400, 100)); w != 1 || h != 1 {
+		t.Errorf("outputSize = %dx%d, want 1x1", w, h)
+	}
+
+	var out strings.Builder
+	if err := New(Options{Width: 1}).Render(&out, image.NewGray(image.Rect(0, 0, 400, 100))); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != " \n" {
+		t.Errorf("Render = %q, want one blank cell", out.String())
+	}
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestModesReturnWriteErrors(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 16, 16))
+	opts := New(Options{Width: 4, Height: 2}).Options()
+
+	for _, name := range ModeNames() {
+		mode, _ := Lookup(name)
+		if err := mode.Render(failingWriter{}, img, opts); err == nil {
+			t.Errorf("mode %s ignored the write error", name)
+		}
+	}
+}
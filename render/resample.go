@@ -0,0 +1,100 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+
+	"golang.org/x/image/draw"
+)
+
+// Resampling kernels by name
+var resamplers = map[string]draw.Interpolator{
+	"nearest":     draw.NearestNeighbor,
+	"box":         boxKernel,
+	"bilinear":    draw.BiLinear,
+	"catmull-rom": draw.CatmullRom,
+	"lanczos3":    lanczos3Kernel,
+}
+
+// boxKernel averages every source pixel a target pixel covers, weighting
+// partially covered pixels by their coverage
+var boxKernel = &draw.Kernel{
+	Support: 0.5,
+	At: func(t float64) float64 {
+		return 1
+	},
+}
+
+// lanczos3Kernel is the three-lobed windowed sinc kernel
+var lanczos3Kernel = &draw.Kernel{
+	Support: 3,
+	At: func(t float64) float64 {
+		return sinc(t) * sinc(t/3)
+	},
+}
+
+// sinc is the normalized sinc function sin(πx)/(πx)
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	x *= math.Pi
+	return math.Sin(x) / x
+}
+
+// Resamplers returns the names of all resampling kernels sorted alphabetically
+func Resamplers() []string {
+	names := make([]string, 0, len(resamplers))
+	for name := range resamplers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GridMode is implemented by modes that sample the image on a regular grid
+// of sub-cell pixels. When Options.Resample is set, the Renderer resizes the
+// image to exactly that grid before calling Render; other modes are given
+// the image as is.
+type GridMode interface {
+	Mode
+
+	// Grid returns the number of samples per cell across and down
+	Grid() (cols, rows int)
+}
+
+// gridMode is a ModeFunc with a fixed sampling grid
+type gridMode struct {
+	ModeFunc
+	cols, rows int
+}
+
+// Grid implements GridMode
+func (m gridMode) Grid() (int, int) {
+	return m.cols, m.rows
+}
+
+// resample resizes img to the sampling grid of mode with the opts.Resample
+// kernel, given options whose Height fit has already filled in
+func resample(img image.Image, mode Mode, opts Options) (image.Image, Options, error) {
+	if opts.Resample == "" {
+		return img, opts, nil
+	}
+	kernel, ok := resamplers[opts.Resample]
+	if !ok {
+		return nil, opts, fmt.Errorf("unknown resampling kernel %q", opts.Resample)
+	}
+	grid, ok := mode.(GridMode)
+	if !ok {
+		return img, opts, nil
+	}
+
+	cols, rows := grid.Grid()
+	bounds := img.Bounds()
+
+	dst := image.NewRGBA64(image.Rect(0, 0, opts.Width*cols, opts.Height*rows))
+	kernel.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst, opts, nil
+}
@@ -0,0 +1,250 @@
+package render
+
+import (
+	"image"
+	"math"
+)
+
+// LumaModel selects how RGB colors are reduced to brightness
+type LumaModel int
+
+const (
+	LumaRec601     LumaModel = iota // 0.299 R + 0.587 G + 0.114 B
+	LumaRec709                      // 0.2126 R + 0.7152 G + 0.0722 B
+	LumaPerceptual                  // CIE L* lightness of the Rec.709 luminance
+)
+
+// linearTable decodes 8-bit sRGB channel values to linear light
+var linearTable = func() [256]float64 {
+	var t [256]float64
+	for i := range t {
+		t[i] = srgbToLinear(uint8(i))
+	}
+	return t
+}()
+
+// sampleRegion samples a rectangular region and returns average grayscale value
+func sampleRegion(img image.Image, minX, minY, maxX, maxY int, opts Options) int {
+	bounds := img.Bounds()
+	
+	// Clamp to image bounds
+	if minX < bounds.Min.X { minX = bounds.Min.X }
+	if maxX >= bounds.Max.X { maxX = bounds.Max.X - 1 }
+	if minY < bounds.Min.Y { minY = bounds.Min.Y }
+	if maxY >= bounds.Max.Y { maxY = bounds.Max.Y - 1 }
+	
+	// Widen degenerate regions, except on a resampled image where each
+	// cell is exactly one pixel
+	if opts.Resample == "" {
+		if minX >= maxX { maxX = minX + 1 }
+		if minY >= maxY { maxY = minY + 1 }
+	}
+	
+	if opts.LinearLight || opts.Luma != LumaRec601 {
+		r, g, b := averageRegion(img, minX, minY, maxX, maxY, opts.LinearLight)
+		return lumaValue(r, g, b, opts)
+	}
+	
+	var totalR, totalG, totalB, totalA uint64
+	
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			totalR += uint64(r)
+			totalG += uint64(g)
+			totalB += uint64(b)
+			totalA += uint64(a)
+		}
+	}
+	
+	if totalA == 0 {
+		return 0
+	}
+	
+	// Average the samples, weighted by alpha
+	avgR := totalR * 0xffff / totalA
+	avgG := totalG * 0xffff / totalA
+	avgB := totalB * 0xffff / totalA
+	
+	// Convert to grayscale
+	gray := (299*avgR + 587*avgG + 114*avgB) / 1000
+	return int(gray >> 8) // Normalize to 0-255
+}
+
+// sampleRegionColor samples a region and returns average RGB values
+func sampleRegionColor(img image.Image, minX, minY, maxX, maxY int, opts Options) (uint8, uint8, uint8) {
+	if opts.LinearLight {
+		r, g, b := averageRegion(img, minX, minY, maxX, maxY, true)
+		return linearToSRGB(r), linearToSRGB(g), linearToSRGB(b)
+	}
+	
+	var totalR, totalG, totalB, totalA uint64
+	
+	for sy := minY; sy <= maxY; sy++ {
+		for sx := minX; sx <= maxX; sx++ {
+			r, g, b, a := img.At(sx, sy).RGBA()
+			totalR += uint64(r)
+			totalG += uint64(g)
+			totalB += uint64(b)
+			totalA += uint64(a)
+		}
+	}
+	
+	if totalA == 0 {
+		return 0, 0, 0
+	}
+	
+	// Average weighted by alpha and convert from 16-bit to 8-bit
+	avgR := uint8((totalR * 0xffff / totalA) >> 8)
+	avgG := uint8((totalG * 0xffff / totalA) >> 8)
+	avgB := uint8((totalB * 0xffff / totalA) >> 8)
+	
+	return avgR, avgG, avgB
+}
+
+// cellBounds maps cell (x, y) of an outWidth x outHeight grid onto a
+// width x height image and returns the inclusive pixel region it covers.
+// Unlike the legacy renderers, neighbouring cells never share pixels.
+func cellBounds(x, y, outWidth, outHeight, width, height int) (minX, minY, maxX, maxY int) {
+	minX = x * width / outWidth
+	maxX = (x+1)*width/outWidth - 1
+	minY = y * height / outHeight
+	maxY = (y+1)*height/outHeight - 1
+	
+	// Always cover at least one pixel when upscaling
+	if maxX < minX { maxX = minX }
+	if maxY < minY { maxY = minY }
+	if maxX >= width { maxX = width - 1 }
+	if maxY >= height { maxY = height - 1 }
+	
+	return minX, minY, maxX, maxY
+}
+
+// averageRegion returns the average color of an inclusive region as 0-1
+// channel values, averaged in linear light when linear is set (and then
+// returned linear) or as raw sRGB values otherwise. Like sampleRegion and
+// sampleRegionColor it weights pixels by alpha, so the result is the
+// un-premultiplied color of the visible pixels.
+func averageRegion(img image.Image, minX, minY, maxX, maxY int, linear bool) (float64, float64, float64) {
+	var totalR, totalG, totalB, totalA float64
+	
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			if a == 0 {
+				continue
+			}
+			if linear {
+				// Decode the straight color, then weight it by alpha
+				w := float64(a) / 0xffff
+				totalR += linearTable[r*0xffff/a>>8] * w
+				totalG += linearTable[g*0xffff/a>>8] * w
+				totalB += linearTable[b*0xffff/a>>8] * w
+				totalA += w
+			} else {
+				totalR += float64(r) / 0xffff
+				totalG += float64(g) / 0xffff
+				totalB += float64(b) / 0xffff
+				totalA += float64(a) / 0xffff
+			}
+		}
+	}
+	
+	if totalA == 0 {
+		return 0, 0, 0
+	}
+	return totalR / totalA, totalG / totalA, totalB / totalA
+}
+
+// luma converts an RGB color to a 0-255 grayscale value using the
+// configured luma model, in linear light when LinearLight is set
+func luma(r, g, b uint8, opts Options) int {
+	if !opts.LinearLight && opts.Luma == LumaRec601 {
+		return (299*int(r) + 587*int(g) + 114*int(b)) / 1000
+	}
+	
+	if opts.LinearLight {
+		return lumaValue(linearTable[r], linearTable[g], linearTable[b], opts)
+	}
+	return lumaValue(float64(r)/255, float64(g)/255, float64(b)/255, opts)
+}
+
+// lumaValue converts 0-1 channel values, linear when opts.LinearLight is set
+// and sRGB-encoded otherwise, to a 0-255 grayscale value
+func lumaValue(r, g, b float64, opts Options) int {
+	var y float64
+	switch opts.Luma {
+	case LumaPerceptual:
+		// L* is defined on linear luminance whatever the sampling mode
+		if !opts.LinearLight {
+			r, g, b = decodeSRGB(r), decodeSRGB(g), decodeSRGB(b)
+		}
+		return int(lightness(0.2126*r+0.7152*g+0.0722*b)*255/100 + 0.5)
+	case LumaRec709:
+		y = 0.2126*r + 0.7152*g + 0.0722*b
+	default:
+		y = 0.299*r + 0.587*g + 0.114*b
+	}
+	
+	// Re-encode linear luminance so mid gray still maps mid ramp
+	if opts.LinearLight {
+		y = encodeSRGB(y)
+	}
+	return int(y*255 + 0.5)
+}
+
+// lightness converts relative luminance (0-1) to CIE L* (0-100)
+func lightness(y float64) float64 {
+	if y <= 216.0/24389.0 {
+		return y * 24389.0 / 27.0
+	}
+	return 116*math.Cbrt(y) - 16
+}
+
+// decodeSRGB converts a 0-1 sRGB channel value to linear light
+func decodeSRGB(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// encodeSRGB converts a 0-1 linear light value to sRGB
+func encodeSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// linearToSRGB encodes a 0-1 linear light value as an 8-bit sRGB channel
+func linearToSRGB(v float64) uint8 {
+	e := encodeSRGB(v)
+	if e <= 0 {
+		return 0
+	}
+	if e >= 1 {
+		return 255
+	}
+	return uint8(e*255 + 0.5)
+}
+
+// applyContrast adjusts the contrast of a grayscale value
+func applyContrast(gray int, contrast float64) int {
+	if contrast == 1.0 {
+		return gray
+	}
+	
+	// Apply contrast: ((gray/255 - 0.5) * contrast + 0.5) * 255
+	normalized := float64(gray) / 255.0
+	adjusted := (normalized-0.5)*contrast + 0.5
+	
+	// Clamp to 0-255 range
+	if adjusted < 0 {
+		adjusted = 0
+	} else if adjusted > 1 {
+		adjusted = 1
+	}
+	
+	return int(adjusted * 255)
+} 
\ No newline at end of file
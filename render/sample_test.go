@@ -0,0 +1,28 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestSampleRegionColorUnpremultiplies(t *testing.T) {
+	// A half-transparent red pixel next to a fully transparent one
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 200, A: 128})
+
+	for _, linear := range []bool{false, true} {
+		opts := Options{LinearLight: linear}
+		r, g, b := sampleRegionColor(img, 0, 0, 1, 0, opts)
+		if r < 198 || r > 202 || g != 0 || b != 0 {
+			t.Errorf("LinearLight=%v: sampleRegionColor = (%d, %d, %d), want about (200, 0, 0)", linear, r, g, b)
+		}
+	}
+
+	// Composited images are opaque, so the background still shows
+	opts := Options{Width: 1, Background: SolidBackground(color.White)}
+	_, g, _ := sampleRegionColor(composite(img, opts), 0, 0, 1, 0, opts)
+	if g < 189 || g > 193 {
+		t.Errorf("composited green = %d, want about 191", g)
+	}
+}
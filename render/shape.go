@@ -0,0 +1,193 @@
+package render
+
+import (
+	"image"
+	"io"
+	"strings"
+	"math"
+	"sync"
+
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Each cell and each glyph is compared as a grid of shapeCols x shapeRows
+// coverage values
+const (
+	shapeCols = 3
+	shapeRows = 3
+)
+
+// Cells whose sub-region brightness has a standard deviation below this
+// (on a 0-1 scale) are treated as flat and use the brightness ramp
+const shapeEdgeThreshold = 0.15
+
+// glyphShape is the ink coverage pattern of a printable glyph per
+// sub-region, normalized to zero mean and unit standard deviation
+type glyphShape struct {
+	char     string
+	coverage [shapeCols * shapeRows]float64
+}
+
+var (
+	glyphShapesOnce sync.Once
+	glyphShapes     []glyphShape
+)
+
+// ModeShape is the name of the shape-matching ASCII renderer
+const ModeShape = "shape"
+
+func init() {
+	Register(ModeShape, "ASCII glyphs matched to each cell's shape, preserving edges and diagonals", gridMode{imageToShapes, shapeCols, shapeRows})
+}
+
+// loadGlyphShapes measures the printable ASCII glyphs of the embedded
+// 7x13 bitmap font
+func loadGlyphShapes() []glyphShape {
+	glyphShapesOnce.Do(func() {
+		face := basicfont.Face7x13
+		cellWidth := face.Advance
+		cellHeight := face.Ascent + face.Descent
+
+		for r := rune(' '); r <= '~'; r++ {
+			dr, mask, maskp, _, ok := face.Glyph(fixed.P(0, face.Ascent), r)
+			if !ok {
+				continue
+			}
+
+			var ink, area [shapeCols * shapeRows]float64
+			for y := 0; y < cellHeight; y++ {
+				for x := 0; x < cellWidth; x++ {
+					i := (y*shapeRows/cellHeight)*shapeCols + x*shapeCols/cellWidth
+					area[i]++
+
+					p := image.Pt(x, y)
+					if !p.In(dr) {
+						continue
+					}
+					_, _, _, a := mask.At(maskp.X+x-dr.Min.X, maskp.Y+y-dr.Min.Y).RGBA()
+					ink[i] += float64(a) / 0xffff
+				}
+			}
+
+			var coverage [shapeCols * shapeRows]float64
+			for i := range coverage {
+				coverage[i] = ink[i] / area[i]
+			}
+
+			// Glyphs without structure (such as space) can never match an edge
+			if normalized, ok := normalizeShape(coverage, 1e-6); ok {
+				glyphShapes = append(glyphShapes, glyphShape{char: string(r), coverage: normalized})
+			}
+		}
+	})
+	return glyphShapes
+}
+
+// normalizeShape scales a coverage pattern to zero mean and unit standard
+// deviation. It reports false for patterns whose deviation is below minStdDev.
+func normalizeShape(pattern [shapeCols * shapeRows]float64, minStdDev float64) ([shapeCols * shapeRows]float64, bool) {
+	mean := 0.0
+	for _, v := range pattern {
+		mean += v
+	}
+	mean /= float64(len(pattern))
+
+	variance := 0.0
+	for _, v := range pattern {
+		variance += (v - mean) * (v - mean)
+	}
+	stdDev := math.Sqrt(variance / float64(len(pattern)))
+	if stdDev < minStdDev {
+		return pattern, false
+	}
+
+	for i, v := range pattern {
+		pattern[i] = (v - mean) / stdDev
+	}
+	return pattern, true
+}
+
+// imageToShapes converts an image to ASCII art. Flat cells use the
+// brightness ramp like imageToASCII; cells containing an edge use the glyph
+// whose coverage pattern correlates best with the cell's sub-regions.
+func imageToShapes(w io.Writer, img image.Image, opts Options) error {
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+
+	// Calculate output dimensions
+	outWidth, outHeight := opts.outputSize(bounds)
+
+	shapes := loadGlyphShapes()
+	subWidth := outWidth * shapeCols
+	subHeight := outHeight * shapeRows
+
+	for y := 0; y < outHeight; y++ {
+		var row strings.Builder
+		for x := 0; x < outWidth; x++ {
+			if writeBlankCell(&row, img, x, y, outWidth, outHeight, opts) {
+				continue
+			}
+
+			// Measure the brightness of each sub-region
+			var cell [shapeCols * shapeRows]float64
+			for i := range cell {
+				sx, sy := x*shapeCols+i%shapeCols, y*shapeRows+i/shapeCols
+				minX, minY, maxX, maxY := cellBounds(sx, sy, subWidth, subHeight, width, height)
+				r, g, b := sampleRegionColor(img, minX, minY, maxX, maxY, opts)
+				gray := applyContrast(luma(r, g, b, opts), opts.Contrast)
+				if opts.Invert {
+					gray = 255 - gray
+				}
+				cell[i] = float64(gray) / 255.0
+			}
+
+			var char string
+			if normalized, ok := normalizeShape(cell, shapeEdgeThreshold); ok {
+				char = closestGlyph(shapes, normalized)
+			} else {
+				mean := 0.0
+				for _, v := range cell {
+					mean += v
+				}
+				mean /= float64(len(cell))
+				char = grayToASCII(int(mean*255), false, opts.Ramp)
+			}
+
+			// Apply color if enabled
+			if opts.Color != ColorNone {
+				minX, minY, maxX, maxY := cellBounds(x, y, outWidth, outHeight, width, height)
+				r, g, b := sampleRegionColor(img, minX, minY, maxX, maxY, opts)
+				char = colorizeChar(char, r, g, b, opts)
+			}
+
+			row.WriteString(char)
+		}
+		row.WriteString("\n")
+		if _, err := io.WriteString(w, row.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closestGlyph returns the glyph whose normalized coverage pattern has the
+// highest correlation with the normalized cell
+func closestGlyph(shapes []glyphShape, cell [shapeCols * shapeRows]float64) string {
+	best := " "
+	bestScore := math.Inf(-1)
+
+	for _, shape := range shapes {
+		score := 0.0
+		for i, c := range cell {
+			score += c * shape.coverage[i]
+		}
+		if score > bestScore {
+			bestScore = score
+			best = shape.char
+		}
+	}
+
+	return best
+}
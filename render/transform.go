@@ -0,0 +1,227 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Gravity is the edge or corner of the image that crop offsets are
+// measured from. The zero Gravity is GravityNorthWest.
+type Gravity int
+
+const (
+	GravityNorthWest Gravity = iota
+	GravityNorth
+	GravityNorthEast
+	GravityWest
+	GravityCenter
+	GravityEast
+	GravitySouthWest
+	GravitySouth
+	GravitySouthEast
+)
+
+// gravityNames lists the gravity names in Gravity order
+var gravityNames = []string{
+	"northwest", "north", "northeast",
+	"west", "center", "east",
+	"southwest", "south", "southeast",
+}
+
+// ParseGravity parses a gravity name such as "center" or "southeast"
+func ParseGravity(name string) (Gravity, error) {
+	for i, n := range gravityNames {
+		if n == name {
+			return Gravity(i), nil
+		}
+	}
+	return GravityNorthWest, fmt.Errorf("unknown gravity %q (want one of %s)", name, strings.Join(gravityNames, ", "))
+}
+
+// String returns the gravity name
+func (g Gravity) String() string {
+	if g < 0 || int(g) >= len(gravityNames) {
+		return fmt.Sprintf("Gravity(%d)", int(g))
+	}
+	return gravityNames[g]
+}
+
+// cropLength is a crop offset or size in pixels, or in percent of the
+// image width or height
+type cropLength struct {
+	value   float64
+	percent bool
+}
+
+// pixels resolves the length against an image dimension
+func (l cropLength) pixels(size int) int {
+	if l.percent {
+		return int(math.Round(l.value * float64(size) / 100))
+	}
+	return int(l.value)
+}
+
+// Crop is a region to cut out of an image before rendering. The zero Crop
+// keeps the whole image.
+type Crop struct {
+	Gravity Gravity // Anchor the X and Y offsets are measured from
+
+	x, y, width, height cropLength
+	set                 bool
+}
+
+// ParseCrop parses "x,y,w,h" where each value is a pixel count or a
+// percentage such as "25%" of the image width (x, w) or height (y, h)
+func ParseCrop(spec string) (Crop, error) {
+	fields := strings.Split(spec, ",")
+	if len(fields) != 4 {
+		return Crop{}, fmt.Errorf("crop %q must have the form x,y,w,h", spec)
+	}
+
+	var lengths [4]cropLength
+	for i, field := range fields {
+		field = strings.TrimSpace(field)
+		l := cropLength{percent: strings.HasSuffix(field, "%")}
+		v, err := strconv.ParseFloat(strings.TrimSuffix(field, "%"), 64)
+		if err != nil || v < 0 || (!l.percent && v != math.Trunc(v)) {
+			return Crop{}, fmt.Errorf("invalid crop value %q", field)
+		}
+		l.value = v
+		lengths[i] = l
+	}
+	if lengths[2].value == 0 || lengths[3].value == 0 {
+		return Crop{}, fmt.Errorf("crop %q has an empty size", spec)
+	}
+
+	return Crop{x: lengths[0], y: lengths[1], width: lengths[2], height: lengths[3], set: true}, nil
+}
+
+// IsZero reports whether c keeps the whole image
+func (c Crop) IsZero() bool {
+	return !c.set
+}
+
+// Rect resolves the crop region within bounds, clipped to the image
+func (c Crop) Rect(bounds image.Rectangle) image.Rectangle {
+	if !c.set {
+		return bounds
+	}
+
+	w, h := bounds.Dx(), bounds.Dy()
+	cw, ch := c.width.pixels(w), c.height.pixels(h)
+	x, y := c.x.pixels(w), c.y.pixels(h)
+
+	// Columns of the gravity grid anchor x; rows anchor y
+	switch c.Gravity % 3 {
+	case 1:
+		x += (w - cw) / 2
+	case 2:
+		x = w - cw - x
+	}
+	switch c.Gravity / 3 {
+	case 1:
+		y += (h - ch) / 2
+	case 2:
+		y = h - ch - y
+	}
+
+	r := image.Rect(x, y, x+cw, y+ch).Add(bounds.Min)
+	return r.Intersect(bounds)
+}
+
+// Apply returns a view of the crop region of img that shares img's pixels
+func (c Crop) Apply(img image.Image) (image.Image, error) {
+	if !c.set {
+		return img, nil
+	}
+
+	r := c.Rect(img.Bounds())
+	if r.Empty() {
+		return nil, fmt.Errorf("crop region lies outside the %dx%d image", img.Bounds().Dx(), img.Bounds().Dy())
+	}
+	return &croppedImage{img: img, rect: r}, nil
+}
+
+// Flip returns img mirrored left to right when horizontal is set, or top to
+// bottom otherwise, as a zero-copy view
+func Flip(img image.Image, horizontal bool) image.Image {
+	if horizontal {
+		return Orient(img, OrientationFlipH)
+	}
+	return Orient(img, OrientationFlipV)
+}
+
+// Rotate returns img turned clockwise by degrees. Quarter turns are exact,
+// zero-copy views; other angles sample the nearest pixel and leave the
+// corners of the enlarged bounds transparent.
+func Rotate(img image.Image, degrees float64) image.Image {
+	degrees = math.Mod(degrees, 360)
+	if degrees < 0 {
+		degrees += 360
+	}
+
+	switch degrees {
+	case 0:
+		return img
+	case 90:
+		return Orient(img, OrientationRotate90)
+	case 180:
+		return Orient(img, OrientationRotate180)
+	case 270:
+		return Orient(img, OrientationRotate270)
+	}
+
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	src := img.Bounds()
+	w, h := float64(src.Dx()), float64(src.Dy())
+
+	// Round away floating point noise before sizing the new bounds
+	rw := math.Ceil(math.Abs(w*cos) + math.Abs(h*sin) - 1e-9)
+	rh := math.Ceil(math.Abs(w*sin) + math.Abs(h*cos) - 1e-9)
+
+	return &rotatedImage{
+		img:    img,
+		sin:    sin,
+		cos:    cos,
+		bounds: image.Rect(0, 0, int(rw), int(rh)),
+	}
+}
+
+// rotatedImage is a view of an image rotated about its center
+type rotatedImage struct {
+	img      image.Image
+	sin, cos float64
+	bounds   image.Rectangle
+}
+
+// ColorModel implements image.Image interface. The corners are transparent
+// even when the source model has no alpha.
+func (r *rotatedImage) ColorModel() color.Model {
+	return color.RGBA64Model
+}
+
+// Bounds implements image.Image interface
+func (r *rotatedImage) Bounds() image.Rectangle {
+	return r.bounds
+}
+
+// At implements image.Image interface
+func (r *rotatedImage) At(x, y int) color.Color {
+	src := r.img.Bounds()
+
+	// Turn the pixel center back by the angle about the image centers
+	dx := float64(x) + 0.5 - float64(r.bounds.Dx())/2
+	dy := float64(y) + 0.5 - float64(r.bounds.Dy())/2
+	sx := dx*r.cos + dy*r.sin + float64(src.Dx())/2
+	sy := -dx*r.sin + dy*r.cos + float64(src.Dy())/2
+
+	p := image.Pt(src.Min.X+int(math.Floor(sx)), src.Min.Y+int(math.Floor(sy)))
+	if !p.In(src) {
+		return color.Transparent
+	}
+	return r.img.At(p.X, p.Y)
+}
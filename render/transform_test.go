@@ -0,0 +1,105 @@
+package render
+
+import (
+	"image"
+	"testing"
+)
+
+func TestParseCropErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"1,2,3",
+		"1,2,3,4,5",
+		"a,0,10,10",
+		"-1,0,10,10",
+		"1.5,0,10,10",
+		"0,0,0,10",
+		"0,0,10,0%",
+	} {
+		if _, err := ParseCrop(spec); err == nil {
+			t.Errorf("ParseCrop(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestCropRect(t *testing.T) {
+	// A 100x50 image whose bounds do not start at the origin
+	bounds := image.Rect(10, 20, 110, 70)
+
+	tests := []struct {
+		spec    string
+		gravity Gravity
+		want    image.Rectangle
+	}{
+		// 20x10 pixels, 5 and 4 pixels in from the anchor
+		{"5,4,20,10", GravityNorthWest, image.Rect(15, 24, 35, 34)},
+		{"5,4,20,10", GravityNorth, image.Rect(55, 24, 75, 34)},
+		{"5,4,20,10", GravityNorthEast, image.Rect(85, 24, 105, 34)},
+		{"5,4,20,10", GravityWest, image.Rect(15, 44, 35, 54)},
+		{"5,4,20,10", GravityCenter, image.Rect(55, 44, 75, 54)},
+		{"5,4,20,10", GravityEast, image.Rect(85, 44, 105, 54)},
+		{"5,4,20,10", GravitySouthWest, image.Rect(15, 56, 35, 66)},
+		{"5,4,20,10", GravitySouth, image.Rect(55, 56, 75, 66)},
+		{"5,4,20,10", GravitySouthEast, image.Rect(85, 56, 105, 66)},
+
+		// Half the image, offset by 10% of the width and 20% of the height
+		{"10%,20%,50%,50%", GravityNorthWest, image.Rect(20, 30, 70, 55)},
+		{"10%,20%,50%,50%", GravityNorth, image.Rect(45, 30, 95, 55)},
+		{"10%,20%,50%,50%", GravityNorthEast, image.Rect(50, 30, 100, 55)},
+		{"10%,20%,50%,50%", GravityWest, image.Rect(20, 42, 70, 67)},
+		{"10%,20%,50%,50%", GravityCenter, image.Rect(45, 42, 95, 67)},
+		{"10%,20%,50%,50%", GravityEast, image.Rect(50, 42, 100, 67)},
+		{"10%,20%,50%,50%", GravitySouthWest, image.Rect(20, 35, 70, 60)},
+		{"10%,20%,50%,50%", GravitySouth, image.Rect(45, 35, 95, 60)},
+		{"10%,20%,50%,50%", GravitySouthEast, image.Rect(50, 35, 100, 60)},
+
+		// Pixels and percentages mixed
+		{"0,25%, 100%,10", GravityNorthWest, image.Rect(10, 33, 110, 43)},
+
+		// Regions overhanging the image are clipped to it
+		{"90,40,20,20", GravityNorthWest, image.Rect(100, 60, 110, 70)},
+		{"0,0,200%,200%", GravityCenter, bounds},
+		{"10,10,20,20", GravitySouthEast, image.Rect(80, 40, 100, 60)},
+		{"95,0,10,10", GravityNorthEast, image.Rect(10, 20, 15, 30)},
+	}
+
+	for _, tt := range tests {
+		crop, err := ParseCrop(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseCrop(%q): %v", tt.spec, err)
+		}
+		crop.Gravity = tt.gravity
+
+		if got := crop.Rect(bounds); got != tt.want {
+			t.Errorf("crop %q with gravity %s = %v, want %v", tt.spec, tt.gravity, got, tt.want)
+		}
+	}
+}
+
+func TestCropApply(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 100, 50))
+
+	if got, err := (Crop{}).Apply(img); err != nil || got != image.Image(img) {
+		t.Errorf("zero Crop.Apply = %v, %v, want the image unchanged", got, err)
+	}
+
+	crop, err := ParseCrop("10,10,20,20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cropped, err := crop.Apply(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cropped.Bounds(); got != image.Rect(0, 0, 20, 20) {
+		t.Errorf("cropped bounds = %v, want %v", got, image.Rect(0, 0, 20, 20))
+	}
+
+	crop, err = ParseCrop("100,0,10,10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := crop.Apply(img); err == nil {
+		t.Error("Apply of a crop outside the image succeeded, want an error")
+	}
+}
@@ -0,0 +1,278 @@
+package sixel
+
+import "sort"
+
+// colorCount is a distinct color and how many pixels have it
+type colorCount struct {
+	rgb   [3]uint8
+	count int
+}
+
+// histogram returns the distinct opaque colors of pixels in ascending RGB
+// order, so that quantization never depends on map iteration order
+func histogram(pixels []pixel) []colorCount {
+	counts := make(map[[3]uint8]int)
+	for _, p := range pixels {
+		if !p.transparent {
+			counts[p.rgb]++
+		}
+	}
+
+	colors := make([]colorCount, 0, len(counts))
+	for rgb, n := range counts {
+		colors = append(colors, colorCount{rgb, n})
+	}
+	sort.Slice(colors, func(i, j int) bool {
+		a, b := colors[i].rgb, colors[j].rgb
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		if a[1] != b[1] {
+			return a[1] < b[1]
+		}
+		return a[2] < b[2]
+	})
+	return colors
+}
+
+// medianCut builds a palette of at most n colors by repeatedly splitting
+// the box with the widest channel range at the median pixel of that channel
+func medianCut(pixels []pixel, n int) [][3]uint8 {
+	colors := histogram(pixels)
+	if len(colors) == 0 {
+		return [][3]uint8{{0, 0, 0}}
+	}
+
+	boxes := [][]colorCount{colors}
+	for len(boxes) < n {
+		// Split the box with the widest range, favoring earlier boxes on ties
+		best, bestChannel, bestRange := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			channel, r := widestChannel(box)
+			if r > bestRange {
+				best, bestChannel, bestRange = i, channel, r
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		box := boxes[best]
+		sort.SliceStable(box, func(i, j int) bool {
+			return box[i].rgb[bestChannel] < box[j].rgb[bestChannel]
+		})
+
+		// Cut where half of the box's pixels lie on each side
+		total := 0
+		for _, c := range box {
+			total += c.count
+		}
+		cut, seen := 1, box[0].count
+		for cut < len(box)-1 && seen+box[cut].count <= total/2 {
+			seen += box[cut].count
+			cut++
+		}
+
+		boxes[best] = box[:cut]
+		boxes = append(boxes, box[cut:])
+	}
+
+	palette := make([][3]uint8, len(boxes))
+	for i, box := range boxes {
+		var sums [3]int
+		total := 0
+		for _, c := range box {
+			for ch := range sums {
+				sums[ch] += int(c.rgb[ch]) * c.count
+			}
+			total += c.count
+		}
+		for ch := range sums {
+			palette[i][ch] = uint8((sums[ch] + total/2) / total)
+		}
+	}
+	return palette
+}
+
+// widestChannel returns the channel with the largest value range in box
+func widestChannel(box []colorCount) (channel, width int) {
+	for ch := 0; ch < 3; ch++ {
+		lo, hi := 255, 0
+		for _, c := range box {
+			v := int(c.rgb[ch])
+			lo = min(lo, v)
+			hi = max(hi, v)
+		}
+		if hi-lo > width {
+			channel, width = ch, hi-lo
+		}
+	}
+	return channel, width
+}
+
+// octreeNode is a node of the color octree. Leaves hold the color sum of
+// every pixel that reached them.
+type octreeNode struct {
+	children [8]*octreeNode
+	sums     [3]int
+	count    int
+	leaf     bool
+}
+
+// Depth of the octree, one level per bit of each channel
+const octreeDepth = 8
+
+// octree builds a palette of at most n colors by inserting every color into
+// an octree and merging the deepest nodes' children until few enough leaves
+// remain
+func octree(pixels []pixel, n int) [][3]uint8 {
+	colors := histogram(pixels)
+	if len(colors) == 0 {
+		return [][3]uint8{{0, 0, 0}}
+	}
+
+	root := &octreeNode{}
+	levels := make([][]*octreeNode, octreeDepth) // Inner nodes by depth, in creation order
+	leaves := 0
+
+	for _, c := range colors {
+		node := root
+		for depth := 0; depth < octreeDepth; depth++ {
+			bit := 7 - depth
+			i := (c.rgb[0]>>bit&1)<<2 | (c.rgb[1]>>bit&1)<<1 | c.rgb[2]>>bit&1
+			if node.children[i] == nil {
+				child := &octreeNode{leaf: depth == octreeDepth-1}
+				node.children[i] = child
+				if child.leaf {
+					leaves++
+				} else {
+					levels[depth+1] = append(levels[depth+1], child)
+				}
+			}
+			node = node.children[i]
+		}
+		for ch := range node.sums {
+			node.sums[ch] += int(c.rgb[ch]) * c.count
+		}
+		node.count += c.count
+	}
+	levels[0] = []*octreeNode{root}
+
+	// Fold the children of the deepest inner nodes into their parents
+	for depth := octreeDepth - 1; depth >= 0 && leaves > n; depth-- {
+		for _, node := range levels[depth] {
+			if leaves <= n {
+				break
+			}
+			merged := 0
+			for i, child := range node.children {
+				if child == nil {
+					continue
+				}
+				for ch := range node.sums {
+					node.sums[ch] += child.sums[ch]
+				}
+				node.count += child.count
+				node.children[i] = nil
+				merged++
+			}
+			node.leaf = true
+			leaves -= merged - 1
+		}
+	}
+
+	var palette [][3]uint8
+	var collect func(node *octreeNode)
+	collect = func(node *octreeNode) {
+		if node.leaf {
+			var c [3]uint8
+			for ch := range c {
+				c[ch] = uint8((node.sums[ch] + node.count/2) / node.count)
+			}
+			palette = append(palette, c)
+			return
+		}
+		for _, child := range node.children {
+			if child != nil {
+				collect(child)
+			}
+		}
+	}
+	collect(root)
+	return palette
+}
+
+// mapPixels returns the palette index of every pixel, or -1 for transparent
+// pixels, optionally diffusing the quantization error Floyd-Steinberg style
+func mapPixels(pixels []pixel, width int, palette [][3]uint8, dither bool) []int16 {
+	indices := make([]int16, len(pixels))
+	cache := make(map[[3]uint8]int16)
+
+	nearest := func(rgb [3]uint8) int16 {
+		if idx, ok := cache[rgb]; ok {
+			return idx
+		}
+		best, bestDist := 0, -1
+		for i, p := range palette {
+			dr := int(rgb[0]) - int(p[0])
+			dg := int(rgb[1]) - int(p[1])
+			db := int(rgb[2]) - int(p[2])
+			if d := dr*dr + dg*dg + db*db; bestDist < 0 || d < bestDist {
+				best, bestDist = i, d
+			}
+		}
+		cache[rgb] = int16(best)
+		return int16(best)
+	}
+
+	if !dither {
+		for i, p := range pixels {
+			if p.transparent {
+				indices[i] = -1
+			} else {
+				indices[i] = nearest(p.rgb)
+			}
+		}
+		return indices
+	}
+
+	// Error carried to the current and next row, one entry per channel
+	height := len(pixels) / width
+	current := make([][3]float64, width+2)
+	next := make([][3]float64, width+2)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			p := pixels[i]
+			if p.transparent {
+				indices[i] = -1
+				continue
+			}
+
+			var rgb [3]uint8
+			for ch := range rgb {
+				v := float64(p.rgb[ch]) + current[x+1][ch]
+				rgb[ch] = uint8(min(max(v+0.5, 0), 255))
+			}
+			idx := nearest(rgb)
+			indices[i] = idx
+
+			for ch := range rgb {
+				err := float64(rgb[ch]) - float64(palette[idx][ch])
+				current[x+2][ch] += err * 7 / 16
+				next[x][ch] += err * 3 / 16
+				next[x+1][ch] += err * 5 / 16
+				next[x+2][ch] += err * 1 / 16
+			}
+		}
+		current, next = next, current
+		for x := range next {
+			next[x] = [3]float64{}
+		}
+	}
+	return indices
+}
@@ -0,0 +1,186 @@
+// Package sixel encodes images as DEC Sixel graphics for terminals such as
+// xterm, foot, WezTerm and Windows Terminal.
+//
+// The encoder is pure Go and deterministic: the same image and options
+// always produce the same bytes.
+//
+//	if err := sixel.Encode(os.Stdout, img, sixel.Options{Dither: true}); err != nil {
+//		return err
+//	}
+package sixel
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+)
+
+// Palette quantizer names
+const (
+	MedianCut = "median-cut"
+	Octree    = "octree"
+)
+
+// DefaultColors is the palette size used when Options.Colors is zero
+const DefaultColors = 256
+
+// Options controls how an image is encoded
+type Options struct {
+	Colors    int    // Palette size, 2-256 (DefaultColors if 0)
+	Quantizer string // MedianCut or Octree (MedianCut if empty)
+	Dither    bool   // Diffuse palette error with Floyd-Steinberg dithering
+}
+
+// Encode writes img to w as a Sixel image at its native pixel size. Pixels
+// less than half opaque are left unpainted so the terminal background shows
+// through them.
+func Encode(w io.Writer, img image.Image, opts Options) error {
+	colors := opts.Colors
+	if colors == 0 {
+		colors = DefaultColors
+	}
+	if colors < 2 || colors > 256 {
+		return fmt.Errorf("sixel: palette size %d is not between 2 and 256", colors)
+	}
+
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return fmt.Errorf("sixel: image has dimensions %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	pixels := readPixels(img)
+
+	var palette [][3]uint8
+	switch opts.Quantizer {
+	case "", MedianCut:
+		palette = medianCut(pixels, colors)
+	case Octree:
+		palette = octree(pixels, colors)
+	default:
+		return fmt.Errorf("sixel: unknown quantizer %q", opts.Quantizer)
+	}
+
+	indices := mapPixels(pixels, bounds.Dx(), palette, opts.Dither)
+
+	bw := bufio.NewWriter(w)
+	writeSixel(bw, indices, bounds.Dx(), bounds.Dy(), palette)
+	return bw.Flush()
+}
+
+// pixel is an opaque sRGB color, or a pixel left unpainted
+type pixel struct {
+	rgb         [3]uint8
+	transparent bool
+}
+
+// readPixels converts img to unpremultiplied 8-bit pixels in row order
+func readPixels(img image.Image) []pixel {
+	bounds := img.Bounds()
+	pixels := make([]pixel, 0, bounds.Dx()*bounds.Dy())
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			if a < 0x8000 {
+				pixels = append(pixels, pixel{transparent: true})
+				continue
+			}
+			pixels = append(pixels, pixel{rgb: [3]uint8{
+				uint8(r * 0xff / a),
+				uint8(g * 0xff / a),
+				uint8(b * 0xff / a),
+			}})
+		}
+	}
+	return pixels
+}
+
+// writeSixel writes the DCS sequence for an image of palette indices, where
+// -1 marks an unpainted pixel
+func writeSixel(w *bufio.Writer, indices []int16, width, height int, palette [][3]uint8) {
+	// P2=1 leaves pixels no color is drawn on at the terminal background.
+	// Raster attributes give a 1:1 pixel aspect and the image size.
+	fmt.Fprintf(w, "\033P0;1;0q\"1;1;%d;%d", width, height)
+
+	// Color registers take RGB percentages
+	for i, c := range palette {
+		fmt.Fprintf(w, "#%d;2;%d;%d;%d", i,
+			(int(c[0])*100+127)/255, (int(c[1])*100+127)/255, (int(c[2])*100+127)/255)
+	}
+
+	row := make([]byte, width)
+	used := make([]bool, len(palette))
+
+	for top := 0; top < height; top += 6 {
+		bottom := min(top+6, height)
+
+		// Find the colors present in this band of six rows
+		for i := range used {
+			used[i] = false
+		}
+		for y := top; y < bottom; y++ {
+			for _, idx := range indices[y*width : (y+1)*width] {
+				if idx >= 0 {
+					used[idx] = true
+				}
+			}
+		}
+
+		// Draw each color over the band, returning to its start in between
+		first := true
+		for c, ok := range used {
+			if !ok {
+				continue
+			}
+			for x := 0; x < width; x++ {
+				var bits byte
+				for y := top; y < bottom; y++ {
+					if indices[y*width+x] == int16(c) {
+						bits |= 1 << (y - top)
+					}
+				}
+				row[x] = '?' + bits
+			}
+
+			if !first {
+				w.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(w, "#%d", c)
+			writeRun(w, row)
+		}
+
+		if bottom < height {
+			w.WriteByte('-')
+		}
+	}
+
+	w.WriteString("\033\\")
+}
+
+// writeRun writes a row of sixel characters with run-length compression,
+// dropping the trailing empty sixels that '$' makes redundant
+func writeRun(w *bufio.Writer, row []byte) {
+	end := len(row)
+	for end > 0 && row[end-1] == '?' {
+		end--
+	}
+
+	for x := 0; x < end; {
+		run := 1
+		for x+run < end && row[x+run] == row[x] {
+			run++
+		}
+
+		// "!n" only pays off from four repeats on
+		if run >= 4 {
+			fmt.Fprintf(w, "!%d%c", run, row[x])
+		} else {
+			for i := 0; i < run; i++ {
+				w.WriteByte(row[x])
+			}
+		}
+		x += run
+	}
+}
@@ -0,0 +1,241 @@
+package sixel
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// quadrants returns an opaque image split into red, green, blue and white
+// quarters
+func quadrants() image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 12))
+	for y := 0; y < 12; y++ {
+		for x := 0; x < 8; x++ {
+			c := color.NRGBA{R: 0xff, A: 0xff}
+			switch {
+			case x >= 4 && y < 6:
+				c = color.NRGBA{G: 0xff, A: 0xff}
+			case x < 4 && y >= 6:
+				c = color.NRGBA{B: 0xff, A: 0xff}
+			case x >= 4 && y >= 6:
+				c = color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// holes returns an orange image with a fully transparent left column, a
+// barely visible pixel and a half opaque one
+func holes() image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 6, 6))
+	for y := 0; y < 6; y++ {
+		for x := 1; x < 6; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: 0xff, G: 0x80, A: 0xff})
+		}
+	}
+	img.SetNRGBA(3, 2, color.NRGBA{R: 0xff, G: 0x80, A: 0x10})
+	img.SetNRGBA(4, 4, color.NRGBA{R: 0xff, G: 0x80, A: 0x80})
+	return img
+}
+
+// stripe returns a wide single-color image that compresses to one run per
+// band
+func stripe() image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 7))
+	for y := 0; y < 7; y++ {
+		for x := 0; x < 40; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: 0x20, G: 0x40, B: 0x80, A: 0xff})
+		}
+	}
+	return img
+}
+
+// gradient returns a smooth image with more colors than a small palette holds
+func gradient() image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 12))
+	for y := 0; y < 12; y++ {
+		for x := 0; x < 16; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 16), G: uint8(y * 20), B: 0x80, A: 0xff})
+		}
+	}
+	return img
+}
+
+func TestEncodeGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		img  image.Image
+		opts Options
+	}{
+		{"opaque", quadrants(), Options{}},
+		{"opaque-octree", quadrants(), Options{Quantizer: Octree}},
+		{"transparent", holes(), Options{}},
+		{"run", stripe(), Options{}},
+		{"gradient", gradient(), Options{Colors: 8}},
+		{"gradient-octree", gradient(), Options{Colors: 8, Quantizer: Octree}},
+		{"gradient-dither", gradient(), Options{Colors: 8, Dither: true}},
+		{"gradient-octree-dither", gradient(), Options{Colors: 8, Quantizer: Octree, Dither: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, tt.img, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", tt.name+".six")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("output differs from %s:\ngot  %q\nwant %q", golden, buf.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestEncodeTransparent(t *testing.T) {
+	img := holes()
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "\033P0;1;0q") {
+		t.Errorf("output starts %q, want P2=1 so unpainted pixels stay transparent", out[:min(len(out), 8)])
+	}
+
+	painted := paintedPixels(t, out, 6, 6)
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 6; x++ {
+			_, _, _, a := img.At(x, y).RGBA()
+			if want := a >= 0x8000; painted[y][x] != want {
+				t.Errorf("pixel (%d, %d) with alpha %#x painted = %v, want %v", x, y, a, painted[y][x], want)
+			}
+		}
+	}
+}
+
+func TestEncodeRun(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, stripe(), Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A full band of six rows, then one row in the second band
+	for _, run := range []string{"!40~", "!40@"} {
+		if !strings.Contains(buf.String(), run) {
+			t.Errorf("output %q lacks the run %q", buf.String(), run)
+		}
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		img  image.Image
+		opts Options
+	}{
+		{"empty image", image.NewNRGBA(image.Rect(0, 0, 0, 4)), Options{}},
+		{"one color", quadrants(), Options{Colors: 1}},
+		{"too many colors", quadrants(), Options{Colors: 257}},
+		{"unknown quantizer", quadrants(), Options{Quantizer: "k-means"}},
+	}
+
+	for _, tt := range tests {
+		if err := Encode(&bytes.Buffer{}, tt.img, tt.opts); err == nil {
+			t.Errorf("%s: Encode succeeded, want an error", tt.name)
+		}
+	}
+}
+
+// paintedPixels decodes the sixel data of a width x height image and
+// reports which pixels any color was drawn on
+func paintedPixels(t *testing.T, out string, width, height int) [][]bool {
+	t.Helper()
+
+	painted := make([][]bool, height)
+	for y := range painted {
+		painted[y] = make([]bool, width)
+	}
+
+	start := strings.IndexByte(out, 'q')
+	end := strings.LastIndex(out, "\033\\")
+	if start < 0 || end < start {
+		t.Fatalf("output %q is not a sixel sequence", out)
+	}
+	data := out[start+1 : end]
+
+	// number reads the decimal number at data[i:]
+	number := func(i int) (int, int) {
+		n := 0
+		for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+			n = n*10 + int(data[i]-'0')
+			i++
+		}
+		return n, i
+	}
+
+	x, band := 0, 0
+	for i := 0; i < len(data); {
+		switch c := data[i]; {
+		case c == '"' || c == '#':
+			// Raster attributes, color definitions and color selections
+			// only hold numbers and semicolons
+			i++
+			for i < len(data) && (data[i] == ';' || (data[i] >= '0' && data[i] <= '9')) {
+				i++
+			}
+		case c == '$':
+			x = 0
+			i++
+		case c == '-':
+			x = 0
+			band++
+			i++
+		case c == '!':
+			var run int
+			run, i = number(i + 1)
+			for n := 0; n < run; n++ {
+				paint(painted, x+n, band, data[i])
+			}
+			x += run
+			i++
+		case c >= '?' && c <= '~':
+			paint(painted, x, band, c)
+			x++
+			i++
+		default:
+			t.Fatalf("unexpected byte %q in sixel data", c)
+		}
+	}
+	return painted
+}
+
+// paint marks the pixels of sixel character c at column x of band
+func paint(painted [][]bool, x, band int, c byte) {
+	for bit := 0; bit < 6; bit++ {
+		if (c-'?')&(1<<bit) != 0 {
+			painted[band*6+bit][x] = true
+		}
+	}
+}
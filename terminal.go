@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Time to wait for the terminal to answer a query. Terminals that answer end
+// the wait with their device attributes reply, so only silent ones wait this
+// long; it leaves room for slow SSH links and multiplexers.
+const terminalQueryTimeout = time.Second
+
+// Primary device attributes request. Every terminal answers it, so sending
+// it after a query tells us when the terminal has finished replying.
+const deviceAttributesQuery = "\033[c"
+
+// queryTerminal writes query to the controlling terminal in raw mode and
+// returns everything the terminal sent back, up to and including its reply
+// to the device attributes request that follows the query
+func queryTerminal(query string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", err
+	}
+	defer tty.Close()
+
+	// Save the terminal state and switch off line buffering and echo
+	state, err := stty(tty, "-g")
+	if err != nil {
+		return "", err
+	}
+	if _, err := stty(tty, "raw", "-echo"); err != nil {
+		return "", err
+	}
+	defer stty(tty, strings.TrimSpace(state))
+
+	// Inside tmux or screen, ask the outer terminal rather than the
+	// multiplexer
+	request := wrapPassthrough(detectMultiplexer(), []byte(query+deviceAttributesQuery))
+	if _, err := tty.Write(request); err != nil {
+		return "", err
+	}
+
+	replies := make(chan string, 1)
+	go func() {
+		var buf []byte
+		b := make([]byte, 1)
+		for {
+			if _, err := tty.Read(b); err != nil {
+				return
+			}
+			buf = append(buf, b[0])
+
+			// The attributes reply has the form ESC [ ? Ps ; ... c
+			if b[0] == 'c' {
+				i := bytes.LastIndex(buf, []byte("\033[?"))
+				if i >= 0 && len(bytes.Trim(buf[i+3:len(buf)-1], "0123456789;")) == 0 {
+					replies <- string(buf)
+					return
+				}
+			}
+		}
+	}()
+
+	select {
+	case reply := <-replies:
+		return reply, nil
+	case <-time.After(terminalQueryTimeout):
+		return "", fmt.Errorf("terminal did not answer")
+	}
+}
+
+// stty runs stty with args on tty and returns its output
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	output, err := cmd.Output()
+	return string(output), err
+}
+
+var (
+	cellSizeOnce          sync.Once
+	cellWidth, cellHeight float64
+	cellSizeKnown         bool
+)
+
+// cellPixelSize returns the character cell size of the terminal on stdout
+// in pixels, measuring it the first time and reusing the answer afterwards
+func cellPixelSize() (width, height float64, ok bool) {
+	cellSizeOnce.Do(func() {
+		cellWidth, cellHeight, cellSizeKnown = measureCellPixelSize()
+	})
+	return cellWidth, cellHeight, cellSizeKnown
+}
+
+// measureCellPixelSize measures the character cell of the terminal on
+// stdout in pixels, from the window size the kernel reports or else from
+// the size replies to the terminal capability probe
+func measureCellPixelSize() (width, height float64, ok bool) {
+	if cols, rows, w, h, ok := windowPixelSize(os.Stdout); ok {
+		return float64(w) / float64(cols), float64(h) / float64(rows), true
+	}
+
+	caps := terminalCapabilities()
+	return cellSizeFromReplies(caps, getTerminalSize)
+}
+
+// cellSizeFromReplies returns the cell size CSI 16t reported, or else
+// divides the text area size CSI 14t reported by the terminal size
+func cellSizeFromReplies(caps Capabilities, terminalSize func() (int, int)) (width, height float64, ok bool) {
+	if caps.CellWidth > 0 && caps.CellHeight > 0 {
+		return float64(caps.CellWidth), float64(caps.CellHeight), true
+	}
+	if caps.TextAreaWidth > 0 && caps.TextAreaHeight > 0 {
+		cols, rows := terminalSize()
+		if cols > 0 && rows > 0 {
+			return float64(caps.TextAreaWidth) / float64(cols), float64(caps.TextAreaHeight) / float64(rows), true
+		}
+	}
+	return 0, 0, false
+}
+
+// detectCellAspect returns the width of a character cell divided by its
+// height
+func detectCellAspect() (float64, bool) {
+	width, height, ok := cellPixelSize()
+	if !ok {
+		return 0, false
+	}
+
+	// Ignore answers no real font could produce
+	aspect := width / height
+	if aspect < 0.2 || aspect > 2 {
+		return 0, false
+	}
+	return aspect, true
+}
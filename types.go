@@ -1,34 +1,22 @@
 package main
 
-// ASCII characters ordered by brightness (darkest to lightest)
-// Using more characters for better density representation
-const asciiChars = " .':;!>*+%S#@"
-
-// Note: blockChars was removed as it was unused.
-// The project uses halfBlocks for Unicode block rendering.
-
-// Unicode half-block characters for even higher resolution
-const halfBlocks = " ▁▂▃▄▅▆▇█"
-
-// ColorMode represents different color output modes
-type ColorMode int
-
-const (
-	ColorNone ColorMode = iota
-	Color256              // 256-color mode
-	Color24bit            // 24-bit truecolor mode
-)
+import "github.com/e6a5/tiv/render"
 
 // Config holds the CLI options
 type Config struct {
-	Width       int
-	Height      int
-	Invert      bool
-	Contrast    float64
-	UseBlocks   bool
-	Dither      bool
-	Color       ColorMode
-	Preview     bool
-	PreviewMode string
-	NoSplit     bool
-} 
\ No newline at end of file
+	render.Options
+	Preview           bool
+	PreviewMode       string
+	NoSplit           bool
+	KittyPlaceholders bool // Draw Kitty previews with Unicode placeholders
+	NoAutoOrient      bool
+	Crop              render.Crop
+	Rotate            float64
+	Flip              string
+	AutoSize          bool // Neither -w nor -h was given
+}
+
+// previewOptions returns the options inline image previews use
+func (c Config) previewOptions() previewOptions {
+	return previewOptions{placeholders: c.KittyPlaceholders, autoOrient: !c.NoAutoOrient, cellAspect: c.CellAspect}
+}
@@ -6,6 +6,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/e6a5/tiv/render"
 )
 
 // ValidationError represents a validation error with context
@@ -64,6 +67,15 @@ func validateConfig(config *Config) error {
 		}
 	}
 
+	// Validate cell aspect (0 means detect)
+	if config.CellAspect != 0 && (config.CellAspect < 0.1 || config.CellAspect > 2) {
+		return ValidationError{
+			Field:   "cell-aspect",
+			Value:   config.CellAspect,
+			Message: "must be 0 (detect) or between 0.1 and 2",
+		}
+	}
+
 	// Validate contrast
 	if config.Contrast < 0.1 {
 		return ValidationError{
@@ -80,6 +92,126 @@ func validateConfig(config *Config) error {
 		}
 	}
 
+	// Validate edge threshold
+	if config.EdgeThreshold <= 0 || config.EdgeThreshold > 1 {
+		return ValidationError{
+			Field:   "edge-threshold",
+			Value:   config.EdgeThreshold,
+			Message: "must be greater than 0 and at most 1",
+		}
+	}
+
+	// Validate background color cells
+	if config.ColorBackground && config.Color == render.ColorNone {
+		return ValidationError{
+			Field:   "color-bg",
+			Value:   config.ColorBackground,
+			Message: "requires -color",
+		}
+	}
+	if config.Palette.Len() > 0 && config.Color == render.ColorNone {
+		return ValidationError{
+			Field:   "palette",
+			Value:   config.Palette.Len(),
+			Message: "requires -color",
+		}
+	}
+	if utf8.RuneCountInString(config.CellGlyph) > 1 {
+		return ValidationError{
+			Field:   "cell-glyph",
+			Value:   config.CellGlyph,
+			Message: "must be a single character",
+		}
+	}
+
+	// Validate dithering algorithm
+	if config.DitherAlgorithm != "" {
+		isValidAlgo := false
+		for _, algo := range render.DitherAlgorithms() {
+			if config.DitherAlgorithm == algo {
+				isValidAlgo = true
+				break
+			}
+		}
+		if !isValidAlgo {
+			return ValidationError{
+				Field:   "dither-algo",
+				Value:   config.DitherAlgorithm,
+				Message: fmt.Sprintf("must be one of: %s", strings.Join(render.DitherAlgorithms(), ", ")),
+			}
+		}
+	}
+
+	// Validate flip direction
+	if config.Flip != "" && config.Flip != "h" && config.Flip != "v" {
+		return ValidationError{
+			Field:   "flip",
+			Value:   config.Flip,
+			Message: "must be 'h' or 'v'",
+		}
+	}
+
+	// Validate resampling kernel
+	if config.Resample != "" {
+		isValidKernel := false
+		for _, kernel := range render.Resamplers() {
+			if config.Resample == kernel {
+				isValidKernel = true
+				break
+			}
+		}
+		if !isValidKernel {
+			return ValidationError{
+				Field:   "resample",
+				Value:   config.Resample,
+				Message: fmt.Sprintf("must be one of: %s", strings.Join(render.Resamplers(), ", ")),
+			}
+		}
+	}
+
+	// Validate rendering mode (empty means derive from -b and -d)
+	if config.Mode != "" {
+		if _, ok := render.Lookup(config.Mode); !ok {
+			return ValidationError{
+				Field:   "mode",
+				Value:   config.Mode,
+				Message: fmt.Sprintf("must be one of: %s", strings.Join(render.ModeNames(), ", ")),
+			}
+		}
+	}
+
+	// Only the dither mode, and the braille mode with -d, dither at all
+	mode := render.New(config.Options).Options().Mode
+	dithering := mode == render.ModeDither || (mode == render.ModeBraille && config.Dither)
+	if !dithering {
+		if config.DitherAlgorithm != "" {
+			return ValidationError{
+				Field:   "dither-algo",
+				Value:   config.DitherAlgorithm,
+				Message: fmt.Sprintf("only applies to the dither and braille modes, not %q", mode),
+			}
+		}
+		if config.Serpentine {
+			return ValidationError{
+				Field:   "serpentine",
+				Value:   config.Serpentine,
+				Message: fmt.Sprintf("requires dithering with -d in the dither or braille mode, not %q", mode),
+			}
+		}
+	}
+
+	// The two-color modes already spend the cell background on a color
+	switch mode {
+	case render.ModeHalfBlock, render.ModeQuadrant, render.ModeSextant:
+		if config.ColorBackground {
+			return ValidationError{
+				Field:   "color-bg",
+				Value:   config.ColorBackground,
+				Message: fmt.Sprintf("does not apply to the %q mode, which colors both foreground and background", mode),
+			}
+		}
+	}
+
 	// Validate preview mode
 	validPreviewModes := []string{"auto", "terminal", "system"}
 	isValidMode := false
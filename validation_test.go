@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/e6a5/tiv/render"
+)
+
+func TestValidateConfigDitherOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		dither    bool
+		algorithm string
+		serpent   bool
+		field     string // Field of the expected ValidationError, empty if valid
+	}{
+		{name: "algorithm implies dither mode", dither: true, algorithm: "atkinson"},
+		{name: "dither mode", mode: render.ModeDither, algorithm: "bayer4", serpent: true},
+		{name: "braille with -d", mode: render.ModeBraille, dither: true, algorithm: "atkinson", serpent: true},
+		{name: "braille without -d", mode: render.ModeBraille, serpent: true, field: "serpentine"},
+		{name: "halfblock", mode: render.ModeHalfBlock, dither: true, algorithm: "atkinson", field: "dither-algo"},
+		{name: "ascii serpentine", serpent: true, field: "serpentine"},
+		{name: "shape serpentine", mode: render.ModeShape, dither: true, serpent: true, field: "serpentine"},
+	}
+
+	for _, tt := range tests {
+		config := Config{PreviewMode: "auto"}
+		config.Width = 80
+		config.Contrast = 1
+		config.EdgeThreshold = render.DefaultEdgeThreshold
+		config.Mode = tt.mode
+		config.Dither = tt.dither
+		config.DitherAlgorithm = tt.algorithm
+		config.Serpentine = tt.serpent
+
+		err := validateConfig(&config)
+		var verr ValidationError
+		switch {
+		case tt.field == "" && err != nil:
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		case tt.field != "" && !errors.As(err, &verr):
+			t.Errorf("%s: got %v, want a ValidationError for %s", tt.name, err, tt.field)
+		case tt.field != "" && verr.Field != tt.field:
+			t.Errorf("%s: error for %s, want %s", tt.name, verr.Field, tt.field)
+		}
+	}
+}
+
+func TestValidateConfigColorBackground(t *testing.T) {
+	tests := []struct {
+		mode  string
+		valid bool
+	}{
+		{render.ModeASCII, true},
+		{render.ModeBraille, true},
+		{render.ModeHalfBlock, false},
+		{render.ModeQuadrant, false},
+		{render.ModeSextant, false},
+	}
+
+	for _, tt := range tests {
+		config := Config{PreviewMode: "auto"}
+		config.Width = 80
+		config.Contrast = 1
+		config.EdgeThreshold = render.DefaultEdgeThreshold
+		config.Color = render.Color24bit
+		config.ColorBackground = true
+		config.Mode = tt.mode
+
+		err := validateConfig(&config)
+		var verr ValidationError
+		switch {
+		case tt.valid && err != nil:
+			t.Errorf("%s: unexpected error: %v", tt.mode, err)
+		case !tt.valid && (!errors.As(err, &verr) || verr.Field != "color-bg"):
+			t.Errorf("%s: got %v, want a ValidationError for color-bg", tt.mode, err)
+		}
+	}
+}
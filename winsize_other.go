@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package main
+
+import "os"
+
+// windowPixelSize is not available on this platform; the cell size is
+// queried from the terminal instead
+func windowPixelSize(f *os.File) (cols, rows, width, height int, ok bool) {
+	return 0, 0, 0, 0, false
+}